@@ -0,0 +1,144 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package action contains the HTTP handler for Mattermost interactive
+// message actions [1].
+//
+// When user click the button or select an option in message attachment,
+// Mattermost send HTTP POST request to the integration URL.
+// The Handler decode the request, dispatch it to the Callback registered
+// with the action ID in the request context, and write the response back to
+// Mattermost.
+//
+// Mattermost does not send the ID of action in the request, so the action
+// ID must be set in the integration context with key ContextActionID.
+// The hooks/logrus package set the ContextActionID, ContextFingerprint,
+// and ContextMessage automatically for each action in the log attachment.
+//
+// Each request must contains the shared secret in the integration context
+// with key ContextSecret, equal to the Options.Secret.
+//
+// # Example
+//
+//	handler, err := action.NewHandler(action.Options{
+//		Secret: secret,
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	handler.Register("ack", action.Acknowledge())
+//	handler.Register("silence", action.Mute(hook, time.Hour, 24*time.Hour))
+//
+//	http.Handle("/mattermost/action", handler)
+//
+// [1] https://developers.mattermost.com/integrate/plugins/interactive-messages/
+package action
+
+import (
+	"fmt"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// List of reserved keys in integration context, see
+// webhook.ContextActionID.
+const (
+	// ContextActionID define the key for action ID, used to dispatch
+	// the request to the Callback.
+	ContextActionID = webhook.ContextActionID
+
+	// ContextFingerprint define the key for fingerprint of log entry
+	// that trigger the message.
+	ContextFingerprint = webhook.ContextFingerprint
+
+	// ContextMessage define the key for the message of log entry that
+	// trigger the message.
+	ContextMessage = webhook.ContextMessage
+
+	// ContextSelectedOption define the key where Mattermost store the
+	// value of selected option in select menu.
+	ContextSelectedOption = webhook.ContextSelectedOption
+
+	// ContextSecret define the key for shared secret that authenticate
+	// the request, see Options.Secret.
+	ContextSecret = webhook.ContextSecret
+)
+
+// Request define the request that Mattermost send when the action is
+// triggered.
+type Request struct {
+	// Context contains the integration context of action.
+	Context map[string]interface{} `json:"context"`
+
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	TeamID      string `json:"team_id"`
+	TeamDomain  string `json:"team_domain"`
+	PostID      string `json:"post_id"`
+	TriggerID   string `json:"trigger_id"`
+	Type        string `json:"type"`
+	DataSource  string `json:"data_source"`
+}
+
+// ActionID return the action ID from request context.
+func (req *Request) ActionID() string {
+	return req.contextString(ContextActionID)
+}
+
+// Fingerprint return the fingerprint of log entry from request context.
+func (req *Request) Fingerprint() string {
+	return req.contextString(ContextFingerprint)
+}
+
+// Message return the message of log entry from request context.
+func (req *Request) Message() string {
+	return req.contextString(ContextMessage)
+}
+
+// SelectedOption return the value of selected option in select menu.
+func (req *Request) SelectedOption() string {
+	return req.contextString(ContextSelectedOption)
+}
+
+// contextString return the value of context `key` as string.
+func (req *Request) contextString(key string) string {
+	v, ok := req.Context[key]
+	if !ok || v == nil {
+		return ""
+	}
+	s, ok := v.(string)
+	if ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Response define the response for Mattermost after action is handled.
+type Response struct {
+	// Update define the new content of the post where the action
+	// triggered.
+	Update *Update `json:"update,omitempty"`
+
+	// EphemeralText define the text that only visible to the user that
+	// trigger the action.
+	EphemeralText string `json:"ephemeral_text,omitempty"`
+
+	// SkipSlackParsing define whether Mattermost should not convert the
+	// Slack markup in the response.
+	SkipSlackParsing bool `json:"skip_slack_parsing,omitempty"`
+}
+
+// Update define the new content of the post.
+type Update struct {
+	// Props define the new properties of post, for example the
+	// "attachments".
+	// Mattermost replace all of the post properties with Props, so if
+	// its nil the original attachments are removed.
+	Props map[string]interface{} `json:"props,omitempty"`
+
+	// Message define the new text of post.
+	Message string `json:"message"`
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package action
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/internal/duration"
+)
+
+// DefaultMuteMax define the default maximum duration of Mute.
+const DefaultMuteMax = 24 * time.Hour
+
+// ErrFingerprintEmpty define an error when the request context does not
+// contains the fingerprint of log entry.
+var ErrFingerprintEmpty = errors.New("empty fingerprint")
+
+// Muter define the interface to suppress the log entries with the same
+// fingerprint, implemented by logrus Hook in hooks/logrus.
+type Muter interface {
+	Mute(fingerprint string, d time.Duration)
+}
+
+// Acknowledge return the Callback that update the post to mark the alert
+// as acknowledged by the user that trigger the action.
+// The post attachments are replaced with the text,
+//
+//	:white_check_mark: Acknowledged by @user: <message>
+func Acknowledge() Callback {
+	return func(req *Request) (res *Response, err error) {
+		res = &Response{
+			Update: &Update{
+				Message: updateMessage(req,
+					":white_check_mark: Acknowledged"),
+			},
+		}
+		return res, nil
+	}
+}
+
+// Mute return the Callback that mute the log entries with the fingerprint
+// in request context for duration `d`.
+//
+// If the action is select menu, the value of selected option is parsed as
+// duration, for example "30m" or "24h", and used instead of `d`.
+// The duration must be greater than zero, and the duration greater than
+// `max` is reduced to `max`.
+// If `max` is zero or negative, it default to DefaultMuteMax.
+//
+// The post attachments are replaced with the text,
+//
+//	:mute: Silenced for <duration> by @user: <message>
+func Mute(muter Muter, d, max time.Duration) Callback {
+	if max <= 0 {
+		max = DefaultMuteMax
+	}
+	return func(req *Request) (res *Response, err error) {
+		var (
+			logp = "Mute"
+			fp   = req.Fingerprint()
+			dur  = d
+		)
+
+		if len(fp) == 0 {
+			return nil, ErrFingerprintEmpty
+		}
+
+		opt := req.SelectedOption()
+		if len(opt) > 0 {
+			dur, err = time.ParseDuration(opt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid duration %q: %w",
+					logp, opt, err)
+			}
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("%s: invalid duration %q: must be greater than zero",
+				logp, duration.Format(dur))
+		}
+		if dur > max {
+			dur = max
+		}
+
+		muter.Mute(fp, dur)
+
+		res = &Response{
+			Update: &Update{
+				Message: updateMessage(req,
+					":mute: Silenced for "+duration.Format(dur)),
+			},
+		}
+		return res, nil
+	}
+}
+
+// updateMessage return the text for updated post, with the user name and
+// message of log entry, if any.
+func updateMessage(req *Request, prefix string) (msg string) {
+	msg = prefix
+	if len(req.UserName) > 0 {
+		msg += " by @" + req.UserName
+	}
+	if text := req.Message(); len(text) > 0 {
+		msg += ": " + text
+	}
+	return msg
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package action
+
+import (
+	"testing"
+	"time"
+)
+
+type testMuter struct {
+	mutes map[string]time.Duration
+}
+
+func (muter *testMuter) Mute(fingerprint string, d time.Duration) {
+	muter.mutes[fingerprint] = d
+}
+
+func TestAcknowledge(t *testing.T) {
+	req := &Request{
+		UserName: "alice",
+		Context: map[string]interface{}{
+			ContextMessage: "disk full",
+		},
+	}
+
+	res, err := Acknowledge()(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := ":white_check_mark: Acknowledged by @alice: disk full"
+	assert(t, exp, res.Update.Message, true)
+}
+
+func TestMute(t *testing.T) {
+	var (
+		muter = &testMuter{
+			mutes: make(map[string]time.Duration),
+		}
+		cb = Mute(muter, time.Hour, 24*time.Hour)
+	)
+
+	tests := []struct {
+		req    *Request
+		desc   string
+		expMsg string
+		expErr string
+		expDur time.Duration
+	}{
+		{
+			desc:   "With empty fingerprint",
+			req:    &Request{},
+			expErr: ErrFingerprintEmpty.Error(),
+		},
+		{
+			desc: "With button",
+			req: &Request{
+				UserName: "alice",
+				Context: map[string]interface{}{
+					ContextFingerprint: "fp1",
+					ContextMessage:     "disk full",
+				},
+			},
+			expMsg: ":mute: Silenced for 1h by @alice: disk full",
+			expDur: time.Hour,
+		},
+		{
+			desc: "With selected option",
+			req: &Request{
+				UserName: "bob",
+				Context: map[string]interface{}{
+					ContextFingerprint:    "fp1",
+					ContextSelectedOption: "30m",
+				},
+			},
+			expMsg: ":mute: Silenced for 30m by @bob",
+			expDur: 30 * time.Minute,
+		},
+		{
+			desc: "With invalid selected option",
+			req: &Request{
+				Context: map[string]interface{}{
+					ContextFingerprint:    "fp1",
+					ContextSelectedOption: "forever",
+				},
+			},
+			expErr: `Mute: invalid duration "forever": time: invalid duration "forever"`,
+			expDur: 30 * time.Minute,
+		},
+		{
+			desc: "With zero duration",
+			req: &Request{
+				Context: map[string]interface{}{
+					ContextFingerprint:    "fp1",
+					ContextSelectedOption: "0s",
+				},
+			},
+			expErr: `Mute: invalid duration "0s": must be greater than zero`,
+			expDur: 30 * time.Minute,
+		},
+		{
+			desc: "With negative duration",
+			req: &Request{
+				Context: map[string]interface{}{
+					ContextFingerprint:    "fp1",
+					ContextSelectedOption: "-1h",
+				},
+			},
+			expErr: `Mute: invalid duration "-1h": must be greater than zero`,
+			expDur: 30 * time.Minute,
+		},
+		{
+			desc: "With duration greater than maximum",
+			req: &Request{
+				UserName: "carol",
+				Context: map[string]interface{}{
+					ContextFingerprint:    "fp1",
+					ContextSelectedOption: "8760h",
+				},
+			},
+			expMsg: ":mute: Silenced for 24h by @carol",
+			expDur: 24 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		res, err := cb(test.req)
+		if err != nil {
+			assert(t, test.expErr, err.Error(), true)
+			assert(t, test.expDur, muter.mutes["fp1"], true)
+			continue
+		}
+		assert(t, "", test.expErr, true)
+
+		assert(t, test.expMsg, res.Update.Message, true)
+		assert(t, test.expDur, muter.mutes["fp1"], true)
+	}
+}
+
+func TestMuteDefaultMax(t *testing.T) {
+	var (
+		muter = &testMuter{
+			mutes: make(map[string]time.Duration),
+		}
+		cb  = Mute(muter, 48*time.Hour, 0)
+		req = &Request{
+			Context: map[string]interface{}{
+				ContextFingerprint: "fp1",
+			},
+		}
+	)
+
+	_, err := cb(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, DefaultMuteMax, muter.mutes["fp1"], true)
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package action
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxRequestSize define the maximum size of request body.
+const maxRequestSize = 1 << 20
+
+// ErrUnknownAction define an error when the action ID in request has no
+// registered Callback.
+var ErrUnknownAction = errors.New("unknown action")
+
+// ErrSecretEmpty define an error when the Options does not contains the
+// secret.
+var ErrSecretEmpty = errors.New("empty secret")
+
+// Options define the options for Handler.
+type Options struct {
+	// Secret define the shared secret that must be set in the
+	// integration context of each action with key ContextSecret.
+	// The request with different secret is rejected, so only the
+	// actions created by the application can trigger the Callback.
+	// Mattermost does not expose the integration context to the
+	// clients.
+	// This field is required.
+	Secret string
+}
+
+// validate the options.
+func (opts *Options) validate() error {
+	if len(opts.Secret) == 0 {
+		return ErrSecretEmpty
+	}
+	return nil
+}
+
+// isValidSecret return true if the `secret` equal to the Secret.
+func (opts *Options) isValidSecret(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(opts.Secret),
+		[]byte(secret)) == 1
+}
+
+// Callback define the function that handle the action request.
+// If the returned response is nil, empty response is send to Mattermost.
+type Callback func(req *Request) (res *Response, err error)
+
+// Handler define the http.Handler that dispatch the Mattermost action
+// request to the registered Callback.
+type Handler struct {
+	callbacks map[string]Callback
+	opts      Options
+	locker    sync.RWMutex
+}
+
+// NewHandler create new Handler using the Options, without any Callback.
+func NewHandler(opts Options) (handler *Handler, err error) {
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	handler = &Handler{
+		opts:      opts,
+		callbacks: make(map[string]Callback),
+	}
+	return handler, nil
+}
+
+// Register the Callback for action `id`.
+// Registering the same id replace the previous Callback.
+func (handler *Handler) Register(id string, cb Callback) {
+	handler.locker.Lock()
+	handler.callbacks[id] = cb
+	handler.locker.Unlock()
+}
+
+// ServeHTTP decode the action request, dispatch it to the Callback, and
+// write the response as JSON.
+//
+// It will response with status 405 if the method is not POST, 400 if the
+// request cannot be decoded, 401 if the secret in request context is
+// invalid, and 404 if the action is not registered.
+// If the Callback return an error, for example invalid duration in select
+// menu, it will response with status 200 and the error message as
+// ephemeral text, since Mattermost does not show the error response to
+// the user.
+// The secret is removed from the request context before passed to the
+// Callback.
+func (handler *Handler) ServeHTTP(res http.ResponseWriter, httpReq *http.Request) {
+	if httpReq.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		http.Error(res, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		req = &Request{}
+		err error
+	)
+
+	err = json.NewDecoder(io.LimitReader(httpReq.Body, maxRequestSize)).Decode(req)
+	if err != nil {
+		http.Error(res, fmt.Sprintf("invalid request: %s", err),
+			http.StatusBadRequest)
+		return
+	}
+
+	if !handler.opts.isValidSecret(req.contextString(ContextSecret)) {
+		http.Error(res, http.StatusText(http.StatusUnauthorized),
+			http.StatusUnauthorized)
+		return
+	}
+	delete(req.Context, ContextSecret)
+
+	var id = req.ActionID()
+
+	handler.locker.RLock()
+	cb := handler.callbacks[id]
+	handler.locker.RUnlock()
+
+	if cb == nil {
+		http.Error(res, fmt.Sprintf("%s: %q", ErrUnknownAction, id),
+			http.StatusNotFound)
+		return
+	}
+
+	resAction, err := cb(req)
+	if err != nil {
+		resAction = &Response{
+			EphemeralText: err.Error(),
+		}
+	}
+	if resAction == nil {
+		resAction = &Response{}
+	}
+
+	body, err := json.Marshal(resAction)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(body)
+}
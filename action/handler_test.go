@@ -0,0 +1,140 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package action
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	_, err := NewHandler(Options{})
+	assert(t, ErrSecretEmpty, err, true)
+}
+
+func TestHandlerServeHTTP(t *testing.T) {
+	handler, err := NewHandler(Options{
+		Secret: "s3cr3t",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotReq *Request
+
+	handler.Register("ack", func(req *Request) (*Response, error) {
+		gotReq = req
+		return &Response{
+			Update: &Update{
+				Message: "acknowledged",
+			},
+			EphemeralText: "done",
+		}, nil
+	})
+	handler.Register("empty", func(req *Request) (*Response, error) {
+		return nil, nil
+	})
+	handler.Register("fail", func(req *Request) (*Response, error) {
+		return nil, errors.New("callback failed")
+	})
+
+	tests := []struct {
+		desc      string
+		method    string
+		body      string
+		expBody   string
+		expStatus int
+	}{
+		{
+			desc:      "With GET method",
+			method:    http.MethodGet,
+			expStatus: http.StatusMethodNotAllowed,
+			expBody:   "Method Not Allowed\n",
+		},
+		{
+			desc:      "With invalid JSON",
+			method:    http.MethodPost,
+			body:      `{`,
+			expStatus: http.StatusBadRequest,
+			expBody:   "invalid request: unexpected EOF\n",
+		},
+		{
+			desc:      "Without secret",
+			method:    http.MethodPost,
+			body:      `{"context":{"action_id":"ack"}}`,
+			expStatus: http.StatusUnauthorized,
+			expBody:   "Unauthorized\n",
+		},
+		{
+			desc:      "With invalid secret",
+			method:    http.MethodPost,
+			body:      `{"context":{"action_id":"ack","secret":"guess"}}`,
+			expStatus: http.StatusUnauthorized,
+			expBody:   "Unauthorized\n",
+		},
+		{
+			desc:      "With unknown action",
+			method:    http.MethodPost,
+			body:      `{"context":{"action_id":"unknown","secret":"s3cr3t"}}`,
+			expStatus: http.StatusNotFound,
+			expBody:   "unknown action: \"unknown\"\n",
+		},
+		{
+			desc:      "With callback error",
+			method:    http.MethodPost,
+			body:      `{"context":{"action_id":"fail","secret":"s3cr3t"}}`,
+			expStatus: http.StatusOK,
+			expBody:   `{"ephemeral_text":"callback failed"}`,
+		},
+		{
+			desc:      "With empty response",
+			method:    http.MethodPost,
+			body:      `{"context":{"action_id":"empty","secret":"s3cr3t"}}`,
+			expStatus: http.StatusOK,
+			expBody:   `{}`,
+		},
+		{
+			desc:      "With update and ephemeral text",
+			method:    http.MethodPost,
+			body:      `{"user_name":"alice","post_id":"p1","context":{"action_id":"ack","fingerprint":"fp1","secret":"s3cr3t"}}`,
+			expStatus: http.StatusOK,
+			expBody:   `{"update":{"message":"acknowledged"},"ephemeral_text":"done"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		var (
+			httpReq = httptest.NewRequest(test.method, "/",
+				strings.NewReader(test.body))
+			rec = httptest.NewRecorder()
+		)
+
+		handler.ServeHTTP(rec, httpReq)
+
+		assert(t, test.expStatus, rec.Code, true)
+		assert(t, test.expBody, rec.Body.String(), true)
+	}
+
+	assert(t, "alice", gotReq.UserName, true)
+	assert(t, "p1", gotReq.PostID, true)
+	assert(t, "fp1", gotReq.Fingerprint(), true)
+	assert(t, "", gotReq.contextString(ContextSecret), true)
+}
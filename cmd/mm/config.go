@@ -0,0 +1,361 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+const defTimeout = 10 * time.Second
+
+// List of flag names.
+const (
+	flagAttachment = "attachment"
+	flagCAFile     = "ca-file"
+	flagChannel    = "channel"
+	flagCode       = "code"
+	flagConfig     = "config"
+	flagDryRun     = "dry-run"
+	flagIconURL    = "icon-url"
+	flagInsecure   = "insecure"
+	flagLevel      = "level"
+	flagMaxSize    = "max-size"
+	flagTimeout    = "timeout"
+	flagTitle      = "title"
+	flagURL        = "url"
+	flagUsername   = "username"
+)
+
+// envKey define the environment variable or key in configuration file and
+// its flag name.
+type envKey struct {
+	key  string
+	name string
+}
+
+// _envKeys contains list of environment variable or key in configuration
+// file with its flag name.
+// The environment variables are loaded in this order, so if both
+// MM_BOTNAME and MM_USERNAME are set, the MM_USERNAME take precedence.
+var _envKeys = []envKey{
+	{key: "MM_CA_FILE", name: flagCAFile},
+	{key: "MM_CHANNEL", name: flagChannel},
+	{key: "MM_ICON_URL", name: flagIconURL},
+	{key: "MM_INSECURE", name: flagInsecure},
+	{key: "MM_MAX_SIZE", name: flagMaxSize},
+	{key: "MM_TIMEOUT", name: flagTimeout},
+	{key: "MM_URL", name: flagURL},
+
+	// MM_BOTNAME is used by scripts/mm.sh.
+	{key: "MM_BOTNAME", name: flagUsername},
+	{key: "MM_USERNAME", name: flagUsername},
+}
+
+// envFlagName return the flag name of environment variable or key in
+// configuration file.
+func envFlagName(key string) (name string, ok bool) {
+	for _, ek := range _envKeys {
+		if ek.key == key {
+			return ek.name, true
+		}
+	}
+	return "", false
+}
+
+// errEndpointEmpty define an error when the webhook URL is not set.
+var errEndpointEmpty = errors.New("empty webhook URL, set the -url or MM_URL")
+
+// errLevelUnknown define an error when the level name is unknown.
+var errLevelUnknown = errors.New("unknown level")
+
+// config contains the options to send the message.
+type config struct {
+	caFile   string
+	channel  string
+	code     string
+	endpoint string
+	iconURL  string
+	title    string
+	username string
+
+	maxSize int
+	timeout time.Duration
+	level   delivery.Level
+
+	attachment bool
+	dryRun     bool
+	insecure   bool
+}
+
+// newFlagSet create the command line flags.
+// The flag value is not bound to config, since the config is loaded from
+// file and environment before the flags are applied.
+func newFlagSet(out io.Writer) (fs *flag.FlagSet) {
+	fs = flag.NewFlagSet("mm", flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	fs.Bool(flagAttachment, false, "send the text as attachment")
+	fs.String(flagCAFile, "", "file contains the PEM certificates to verify the server, in addition to system certificates")
+	fs.String(flagChannel, "", "the channel name, override the default channel of webhook")
+	fs.String(flagCode, "", "wrap the text in code block with the language, use \"-\" for plain code block")
+	fs.String(flagConfig, "", "the configuration file, default to $XDG_CONFIG_HOME/mm/config")
+	fs.Bool(flagDryRun, false, "print the payloads instead of sending them")
+	fs.String(flagIconURL, "", "the URL of profile picture")
+	fs.Bool(flagInsecure, false, "skip verifying the server certificate")
+	fs.String(flagLevel, "info", "the log level, one of trace, debug, info, warning, error, fatal, or panic")
+	fs.Int(flagMaxSize, webhook.MaxPostSize, "the maximum number of characters in one post")
+	fs.Duration(flagTimeout, defTimeout, "the timeout to send each post")
+	fs.String(flagTitle, "", "the title of attachment")
+	fs.String(flagURL, "", "the Mattermost incoming webhook URL")
+	fs.String(flagUsername, "", "the user name that send the message, default to hostname")
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: mm [options] [text ...]\n\n"+
+			"Send the text from arguments or standard input to Mattermost.\n"+
+			"If text is given in both, the arguments become the header.\n\n"+
+			"Options:\n")
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// loadConfig load the configuration from file, environment, and command
+// line flags `args`, in that order.
+// It return the configuration and the rest of arguments.
+func loadConfig(args []string, getenv func(string) string, out io.Writer) (
+	cfg *config, rest []string, err error,
+) {
+	cfg = &config{
+		maxSize: webhook.MaxPostSize,
+		timeout: defTimeout,
+		level:   delivery.LevelInfo,
+	}
+
+	fs := newFlagSet(out)
+
+	err = fs.Parse(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := fs.Lookup(flagConfig).Value.String()
+	if len(path) == 0 {
+		path = getenv("MM_CONFIG")
+	}
+	if len(path) > 0 {
+		err = cfg.loadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		path = defConfigFile()
+		err = cfg.loadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+	}
+
+	err = cfg.loadEnv(getenv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil && f.Name != flagConfig {
+			err = cfg.set(f.Name, f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// defConfigFile return the path of default configuration file.
+func defConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mm", "config")
+}
+
+// loadFile load the configuration from file.
+// Each line in file has format "KEY=VALUE", using the same keys as
+// environment variables.
+// The empty line and line started with "#" are ignored.
+func (cfg *config) loadFile(path string) (err error) {
+	var logp = "loadFile"
+
+	if len(path) == 0 {
+		return fmt.Errorf("%s: %w", logp, os.ErrNotExist)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logp, err)
+	}
+	defer f.Close()
+
+	var (
+		scanner = bufio.NewScanner(f)
+		n       int
+	)
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s: %s:%d: missing '='", logp, path, n)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		name, ok := envFlagName(key)
+		if !ok {
+			return fmt.Errorf("%s: %s:%d: unknown key %q", logp, path, n, key)
+		}
+
+		err = cfg.set(name, value)
+		if err != nil {
+			return fmt.Errorf("%s: %s:%d: %w", logp, path, n, err)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", logp, err)
+	}
+
+	return nil
+}
+
+// loadEnv load the configuration from environment variables, in the
+// order of _envKeys.
+func (cfg *config) loadEnv(getenv func(string) string) (err error) {
+	for _, ek := range _envKeys {
+		value := getenv(ek.key)
+		if len(value) == 0 {
+			continue
+		}
+		err = cfg.set(ek.name, value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ek.key, err)
+		}
+	}
+	return nil
+}
+
+// set the configuration `name` with `value`.
+func (cfg *config) set(name, value string) (err error) {
+	switch name {
+	case flagAttachment:
+		cfg.attachment, err = strconv.ParseBool(value)
+	case flagCAFile:
+		cfg.caFile = value
+	case flagChannel:
+		cfg.channel = value
+	case flagCode:
+		cfg.code = value
+	case flagDryRun:
+		cfg.dryRun, err = strconv.ParseBool(value)
+	case flagIconURL:
+		cfg.iconURL = value
+	case flagInsecure:
+		cfg.insecure, err = strconv.ParseBool(value)
+	case flagLevel:
+		cfg.level, err = parseLevel(value)
+	case flagMaxSize:
+		cfg.maxSize, err = strconv.Atoi(value)
+	case flagTimeout:
+		cfg.timeout, err = time.ParseDuration(value)
+	case flagTitle:
+		cfg.title = value
+	case flagURL:
+		cfg.endpoint = value
+	case flagUsername:
+		cfg.username = value
+	default:
+		return fmt.Errorf("unknown option %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return nil
+}
+
+// parseLevel return the level from its `name`, case insensitive.
+// The "warn" is accepted as alias of "warning".
+func parseLevel(name string) (lvl delivery.Level, err error) {
+	name = strings.ToLower(name)
+	if name == "warn" {
+		return delivery.LevelWarning, nil
+	}
+	for lvl = delivery.LevelPanic; lvl <= delivery.LevelTrace; lvl++ {
+		if lvl.String() == name {
+			return lvl, nil
+		}
+	}
+	return 0, errLevelUnknown
+}
+
+// newHTTPClient create the HTTP client based on TLS options.
+func (cfg *config) newHTTPClient() (cl *http.Client, err error) {
+	var (
+		logp   = "newHTTPClient"
+		tlsCfg = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.insecure, //nolint:gosec
+		}
+	)
+
+	if len(cfg.caFile) > 0 {
+		var pem []byte
+
+		pem, err = os.ReadFile(cfg.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logp, err)
+		}
+
+		tlsCfg.RootCAs, err = x509.SystemCertPool()
+		if err != nil {
+			tlsCfg.RootCAs = x509.NewCertPool()
+		}
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificate found in %s",
+				logp, cfg.caFile)
+		}
+	}
+
+	cl = &http.Client{
+		Timeout: cfg.timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+	}
+	return cl, nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+// newGetenv return function that return the environment from map `env`.
+func newGetenv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "config")
+	)
+
+	err := os.WriteFile(path, []byte(`
+# Comment.
+MM_URL = "https://file.example.com/hooks/xxx"
+MM_CHANNEL=from-file
+MM_BOTNAME=file-bot
+MM_TIMEOUT=5s
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		env     map[string]string
+		exp     *config
+		desc    string
+		expErr  string
+		args    []string
+		expRest []string
+	}{
+		{
+			desc: "With configuration file",
+			args: []string{"-config", path, "text"},
+			exp: &config{
+				endpoint: "https://file.example.com/hooks/xxx",
+				channel:  "from-file",
+				username: "file-bot",
+				maxSize:  webhook.MaxPostSize,
+				timeout:  5 * time.Second,
+				level:    delivery.LevelInfo,
+			},
+			expRest: []string{"text"},
+		},
+		{
+			desc: "With environment override file",
+			args: []string{"text"},
+			env: map[string]string{
+				"MM_CONFIG":   path,
+				"MM_CHANNEL":  "from-env",
+				"MM_INSECURE": "true",
+			},
+			exp: &config{
+				endpoint: "https://file.example.com/hooks/xxx",
+				channel:  "from-env",
+				username: "file-bot",
+				maxSize:  webhook.MaxPostSize,
+				timeout:  5 * time.Second,
+				level:    delivery.LevelInfo,
+				insecure: true,
+			},
+			expRest: []string{"text"},
+		},
+		{
+			desc: "With flags override environment",
+			args: []string{"-config", path, "-channel", "from-flag",
+				"-level", "error", "-insecure=false", "-code", "go",
+				"a", "b"},
+			env: map[string]string{
+				"MM_CHANNEL":  "from-env",
+				"MM_INSECURE": "true",
+			},
+			exp: &config{
+				endpoint: "https://file.example.com/hooks/xxx",
+				channel:  "from-flag",
+				code:     "go",
+				username: "file-bot",
+				maxSize:  webhook.MaxPostSize,
+				timeout:  5 * time.Second,
+				level:    delivery.LevelError,
+			},
+			expRest: []string{"a", "b"},
+		},
+		{
+			desc:   "With invalid level",
+			args:   []string{"-config", path, "-level", "loud"},
+			expErr: `invalid level "loud": unknown level`,
+		},
+		{
+			desc:   "With invalid environment",
+			args:   []string{"-config", path},
+			env:    map[string]string{"MM_MAX_SIZE": "big"},
+			expErr: `MM_MAX_SIZE: invalid max-size "big": strconv.Atoi: parsing "big": invalid syntax`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		cfg, rest, err := loadConfig(test.args, newGetenv(test.env),
+			io.Discard)
+		if err != nil {
+			assert(t, test.expErr, err.Error(), true)
+			continue
+		}
+
+		assert(t, test.exp, cfg, true)
+		assert(t, test.expRest, rest, true)
+	}
+}
+
+func TestConfigLoadFile(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "config")
+		cfg  = &config{}
+	)
+
+	err := os.WriteFile(path, []byte("MM_UNKNOWN=x\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cfg.loadFile(path)
+
+	assert(t, `loadFile: `+path+`:1: unknown key "MM_UNKNOWN"`, err.Error(), true)
+}
+
+func TestConfigLoadEnvUsername(t *testing.T) {
+	var (
+		cfg = &config{}
+		env = map[string]string{
+			"MM_BOTNAME":  "bot",
+			"MM_USERNAME": "user",
+		}
+	)
+
+	// Loading several times to make sure the order is not random.
+	for x := 0; x < 10; x++ {
+		err := cfg.loadEnv(newGetenv(env))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert(t, "user", cfg.username, true)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		expErr error
+		in     string
+		exp    delivery.Level
+	}{{
+		in:  "error",
+		exp: delivery.LevelError,
+	}, {
+		in:  "WARN",
+		exp: delivery.LevelWarning,
+	}, {
+		in:  "trace",
+		exp: delivery.LevelTrace,
+	}, {
+		in:     "loud",
+		expErr: errLevelUnknown,
+	}}
+
+	for _, test := range tests {
+		t.Log(test.in)
+
+		got, err := parseLevel(test.in)
+		assert(t, test.expErr, err, true)
+		if err == nil {
+			assert(t, test.exp, got, true)
+		}
+	}
+}
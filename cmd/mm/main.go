@@ -0,0 +1,245 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Program mm send text from arguments or standard input to Mattermost
+// using incoming webhook.
+//
+// It is the replacement of scripts/mm.sh, with proper JSON encoding, TLS
+// verification, and splitting the large text into multiple posts.
+//
+// # Usage
+//
+//	mm [options] [text ...]
+//
+// If the text is given in both arguments and standard input, the
+// arguments become the header of the text, for example
+//
+//	$ make 2>&1 | mm -level=error -code=- "Build failed"
+//
+// # Configuration
+//
+// The options are loaded from configuration file, environment variables,
+// and command line flags, in that order, where the later override the
+// former.
+//
+// The configuration file is read from the -config flag, MM_CONFIG
+// environment variable, or $XDG_CONFIG_HOME/mm/config.
+// Each line in the file has format "KEY=VALUE", using the same keys as the
+// environment variables,
+//
+//	MM_URL      - the incoming webhook URL.
+//	MM_CHANNEL  - the channel name.
+//	MM_USERNAME - the user name, MM_BOTNAME is accepted as alias; if
+//	              both are set, MM_USERNAME take precedence.
+//	MM_ICON_URL - the URL of profile picture.
+//	MM_CA_FILE  - the PEM file contains additional certificates.
+//	MM_INSECURE - skip verifying server certificate, "true" or "false".
+//	MM_MAX_SIZE - the maximum number of characters in one post.
+//	MM_TIMEOUT  - the timeout to send each post, for example "10s".
+//
+// Run "mm -h" for list of flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shuLhan/mattermost-integration/internal/level"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// maxReadSize define the maximum size of text read from standard input.
+const maxReadSize = 16 << 20
+
+func main() {
+	var stdin io.Reader
+
+	// Read the standard input only if its not a terminal.
+	fi, err := os.Stdin.Stat()
+	if err == nil && fi.Mode()&os.ModeCharDevice == 0 {
+		stdin = os.Stdin
+	}
+
+	err = run(os.Args[1:], stdin, os.Stdout, os.Stderr, os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "mm: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run the program using arguments `args`.
+// The text is read from `stdin` if its not nil.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer,
+	getenv func(string) string,
+) (err error) {
+	cfg, rest, err := loadConfig(args, getenv, stderr)
+	if err != nil {
+		return err
+	}
+	if len(cfg.endpoint) == 0 && !cfg.dryRun {
+		return errEndpointEmpty
+	}
+
+	var (
+		header = strings.Join(rest, " ")
+		text   string
+	)
+
+	if stdin != nil {
+		var b []byte
+
+		b, err = io.ReadAll(io.LimitReader(stdin, maxReadSize))
+		if err != nil {
+			return fmt.Errorf("reading standard input: %w", err)
+		}
+		text = strings.TrimRight(string(b), "\n")
+	}
+	if len(text) == 0 {
+		header, text = "", header
+	}
+	if len(text) == 0 {
+		return errors.New("empty text")
+	}
+
+	payloads, err := cfg.newPayloads(header, text)
+	if err != nil {
+		return err
+	}
+
+	if cfg.dryRun {
+		for _, payload := range payloads {
+			fmt.Fprintf(stdout, "%s\n", payload)
+		}
+		return nil
+	}
+
+	if cfg.insecure {
+		fmt.Fprintln(stderr, "mm: WARNING: server certificate is not verified")
+	}
+
+	httpCl, err := cfg.newHTTPClient()
+	if err != nil {
+		return err
+	}
+
+	cl := &webhook.Client{
+		HTTPClient: httpCl,
+		Endpoint:   cfg.endpoint,
+	}
+
+	for x, payload := range payloads {
+		_, err = cl.PostRaw(context.Background(), "", payload)
+		if err != nil {
+			return fmt.Errorf("sending post %d/%d: %w", x+1,
+				len(payloads), err)
+		}
+	}
+
+	return nil
+}
+
+// newPayloads create the JSON payloads of message from `header` and
+// `text`.
+//
+// In text mode, each post has format ":icon: **header**\ntext", where the
+// icon is based on level.
+// In attachment mode, the header become the attachment title, if the
+// title is not set.
+// If the text is larger than maximum size, it is split into several
+// posts, each prefixed with "(n/m)".
+func (cfg *config) newPayloads(header, text string) (
+	payloads [][]byte, err error,
+) {
+	var (
+		icon                  = level.Icons[cfg.level]
+		fenceOpen, fenceClose string
+		title                 string
+	)
+
+	if len(cfg.code) > 0 {
+		lang := cfg.code
+		if lang == "-" {
+			lang = ""
+		}
+		fenceOpen = "```" + lang + "\n"
+		fenceClose = "\n```"
+	}
+
+	if cfg.attachment {
+		title = cfg.title
+		if len(title) == 0 {
+			title, header = header, ""
+		}
+	}
+	if len(header) > 0 {
+		header = "**" + header + "**"
+	}
+
+	overhead := utf8.RuneCountInString(icon+header) + 2 +
+		utf8.RuneCountInString(fenceOpen+fenceClose) +
+		webhook.MaxPartMarkerSize
+	if cfg.maxSize <= overhead {
+		return nil, fmt.Errorf("max-size must be greater than %d", overhead)
+	}
+
+	var (
+		parts       = webhook.SplitText(text, cfg.maxSize-overhead)
+		hostname, _ = os.Hostname()
+	)
+
+	for x, part := range parts {
+		var sb strings.Builder
+
+		sb.WriteString(icon)
+		sb.WriteByte(' ')
+		if len(parts) > 1 {
+			fmt.Fprintf(&sb, "(%d/%d) ", x+1, len(parts))
+		}
+		if len(header) > 0 {
+			sb.WriteString(header)
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(fenceOpen)
+		sb.WriteString(part)
+		sb.WriteString(fenceClose)
+
+		payload := webhook.Payload{
+			Channel:  cfg.channel,
+			Username: cfg.username,
+			IconURL:  cfg.iconURL,
+		}
+		if len(payload.Username) == 0 {
+			payload.Username = hostname
+		}
+		if cfg.attachment {
+			payload.Attachments = []*webhook.Attachment{{
+				Color: level.Colors[cfg.level],
+				Title: title,
+				Text:  sb.String(),
+			}}
+		} else {
+			payload.Text = sb.String()
+		}
+
+		var b []byte
+
+		b, err = payload.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		payloads = append(payloads, b)
+	}
+
+	return payloads, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// setConfigDir set the user configuration directory to empty directory, so
+// the default configuration file is not loaded.
+func setConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+}
+
+func TestRun(t *testing.T) {
+	var bodies []string
+
+	setConfigDir(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+		}))
+	t.Cleanup(srv.Close)
+
+	hostname, _ := os.Hostname()
+
+	tests := []struct {
+		desc   string
+		stdin  string
+		args   []string
+		exp    []string
+		expErr string
+	}{
+		{
+			desc:   "With empty text",
+			args:   []string{"-url", srv.URL},
+			expErr: "empty text",
+		},
+		{
+			desc:   "With empty URL",
+			args:   []string{"text"},
+			expErr: errEndpointEmpty.Error(),
+		},
+		{
+			desc: "With text from arguments",
+			args: []string{"-url", srv.URL, "-channel", "ops",
+				"-username", "bot", `"quoted"`, "text"},
+			exp: []string{
+				`{"channel":"ops","username":"bot","text":":white_circle: \"quoted\" text"}`,
+			},
+		},
+		{
+			desc:  "With header and code block",
+			args:  []string{"-url", srv.URL, "-level", "error", "-code", "-", "Build failed"},
+			stdin: "line 1\n\tline 2\n",
+			exp: []string{
+				`{"username":"` + hostname + "\",\"text\":\":exclamation: **Build failed**\\n```\\nline 1\\n\\tline 2\\n```\"}",
+			},
+		},
+		{
+			desc:  "With attachment",
+			args:  []string{"-url", srv.URL, "-attachment", "-icon-url", "https://example.com/icon.png", "Title"},
+			stdin: "text",
+			exp: []string{
+				`{"username":"` + hostname + `","icon_url":"https://example.com/icon.png","attachments":[{"color":"#FFFFFF","text":":white_circle: text","title":"Title"}]}`,
+			},
+		},
+		{
+			desc:  "With attachment and title",
+			args:  []string{"-url", srv.URL, "-attachment", "-title", "Backup", "-level", "warning", "Disk usage"},
+			stdin: "90%",
+			exp: []string{
+				`{"username":"` + hostname + `","attachments":[{"color":"#9F6000","text":":interrobang: **Disk usage**\n90%","title":"Backup"}]}`,
+			},
+		},
+		{
+			desc:  "With split",
+			args:  []string{"-url", srv.URL, "-max-size", "40"},
+			stdin: "line 1\nline 2",
+			exp: []string{
+				`{"username":"` + hostname + `","text":":white_circle: (1/2) line 1"}`,
+				`{"username":"` + hostname + `","text":":white_circle: (2/2) line 2"}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		var stdin io.Reader
+		if len(test.stdin) > 0 {
+			stdin = strings.NewReader(test.stdin)
+		}
+
+		bodies = nil
+
+		err := run(test.args, stdin, io.Discard, io.Discard,
+			newGetenv(nil))
+		if err != nil {
+			assert(t, test.expErr, err.Error(), true)
+			continue
+		}
+
+		assert(t, test.exp, bodies, true)
+	}
+}
+
+func TestRunDryRunLargeText(t *testing.T) {
+	var (
+		stdout bytes.Buffer
+		stdin  = strings.Repeat("ペンギン \"line\"\n", 3000)
+	)
+
+	setConfigDir(t)
+
+	err := run([]string{"-dry-run", "Header"}, strings.NewReader(stdin),
+		&stdout, io.Discard, newGetenv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert(t, 3, len(lines), true)
+
+	for _, line := range lines {
+		var got struct {
+			Text string
+		}
+
+		err = json.Unmarshal([]byte(line), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if utf8.RuneCountInString(got.Text) > webhook.MaxPostSize {
+			t.Fatalf("post has %d characters", utf8.RuneCountInString(got.Text))
+		}
+	}
+}
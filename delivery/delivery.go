@@ -0,0 +1,539 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package delivery contains the Sender that queue and send the post to
+// Mattermost incoming webhook asynchronously.
+//
+// The package does not depends on any logging framework.
+// The hooks for logrus, slog, zap, and zerolog convert each log entry into
+// Post and send it using the Sender, so all of them have the same features:
+//
+//   - concurrent workers with rate limit,
+//   - retry with exponential backoff,
+//   - overflow policy when the queue is full,
+//   - durable, file-backed queue (see SpoolOptions), and
+//   - delivery metrics (see MetricsSnapshot).
+//
+// # Example
+//
+//	sender, err := delivery.New(delivery.Options{
+//		Endpoint: "https://my.mattermost.org/hooks/xxx",
+//	})
+//	...
+//	err = sender.Push(&delivery.Post{
+//		Payload: webhook.Payload{Text: "disk almost full"},
+//		Level:   delivery.LevelWarning,
+//	})
+//	...
+//	dropped, err := sender.Close(ctx)
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// ErrClosed define an error when pushing post to Sender that has been
+// closed.
+var ErrClosed = errors.New("sender is closed")
+
+// ErrSpooled define an error when the Sender closed with posts that has
+// not been send, but they are kept in spool to be send on the next start.
+var ErrSpooled = errors.New("posts are kept in spool")
+
+// Sender contains the reusable HTTP client, the queue of posts, and the
+// workers that send the post to Mattermost.
+type Sender struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	client    *webhook.Client
+	limiter   *rateLimiter
+	spool     *spool
+	chanPost  chan *Post
+	chanIdle  chan struct{}
+	spoolDone chan struct{}
+
+	// chanStop is closed by Close to stop retrying the post that has
+	// been stored in spool.
+	// It is nil if the spool is disabled.
+	chanStop chan struct{}
+
+	opts Options
+
+	// pending is the number of posts that are queued or being send.
+	pending       int
+	pendingLocker sync.Mutex
+
+	// dropped is the total number of dropped posts.
+	dropped       int64
+	droppedLocker sync.Mutex
+
+	// locker protect the chanPost from being closed while Push is
+	// sending post to it.
+	locker sync.RWMutex
+	closed bool
+}
+
+// New create and start new Sender using the configuration from Options.
+// The error only returned when opening the spool.
+func New(opts Options) (sender *Sender, err error) {
+	opts.setDefault()
+
+	sender = &Sender{
+		opts:     opts,
+		chanPost: make(chan *Post, opts.QueueSize),
+	}
+
+	if len(opts.Spool.Dir) > 0 {
+		sender.spool, err = openSpool(opts.Spool)
+		if err != nil {
+			return nil, err
+		}
+		sender.pending = sender.spool.count
+	}
+
+	sender.ctx, sender.cancel = context.WithCancel(context.Background())
+
+	sender.client = &webhook.Client{
+		HTTPClient: opts.HTTPClient,
+		Endpoint:   opts.Endpoint,
+	}
+	if sender.client.HTTPClient == nil {
+		sender.client.HTTPClient = newHTTPClient()
+	}
+	if opts.RateLimit > 0 {
+		sender.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+
+	if sender.spool != nil {
+		sender.spoolDone = make(chan struct{})
+		sender.chanStop = make(chan struct{})
+		go sender.spoolConsumer()
+	} else {
+		for x := 0; x < opts.Workers; x++ {
+			go sender.consumer()
+		}
+	}
+	if opts.DropReportInterval > 0 && opts.DropReport != nil {
+		go sender.reportDropped()
+	}
+
+	return sender, nil
+}
+
+// Push the post into queue to be send by the workers.
+// If the queue is full, the post is handled based on the overflow
+// policy.
+// If the spool is enabled, the post payload is appended into spool.
+//
+// It will return ErrClosed if the Sender has been closed.
+func (sender *Sender) Push(post *Post) (err error) {
+	sender.locker.RLock()
+	defer sender.locker.RUnlock()
+
+	if sender.closed {
+		return ErrClosed
+	}
+	if sender.spool != nil {
+		return sender.pushSpool(post)
+	}
+
+	sender.addPending()
+	select {
+	case sender.chanPost <- post:
+		m := sender.metricsOf(post)
+		m.add(&m.queued, 1)
+	default:
+		sender.pushOverflow(post)
+	}
+
+	return nil
+}
+
+// TryPush push the post into queue without blocking.
+// It will return false if the queue is full or the Sender has been
+// closed.
+func (sender *Sender) TryPush(post *Post) bool {
+	sender.locker.RLock()
+	defer sender.locker.RUnlock()
+
+	if sender.closed {
+		return false
+	}
+	if sender.spool != nil {
+		return sender.pushSpool(post) == nil
+	}
+
+	sender.addPending()
+	select {
+	case sender.chanPost <- post:
+		m := sender.metricsOf(post)
+		m.add(&m.queued, 1)
+		return true
+	default:
+		sender.donePending(1)
+		return false
+	}
+}
+
+// pushSpool append the post payload into spool.
+func (sender *Sender) pushSpool(post *Post) (err error) {
+	var (
+		payload []byte
+		dropped int
+	)
+
+	payload, err = post.Payload.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	sender.addPending()
+	dropped, err = sender.spool.push(newSpoolRecord(post.Endpoint,
+		post.Level.String(), post.Channel, payload), time.Now())
+	if err != nil {
+		sender.donePending(1)
+		return err
+	}
+
+	m := sender.metricsOf(post)
+	m.add(&m.queued, 1)
+
+	if dropped > 0 {
+		m = sender.metricsUnknown()
+		m.add(&m.dropped, dropped)
+		sender.drop(dropped)
+	}
+
+	return nil
+}
+
+// Flush wait until all queued posts has been send to Mattermost or the
+// context `ctx` is done.
+//
+// On success it will return zero with nil error.
+// If the context is done before all posts has been send, it will return
+// the number of posts that are still queued or being send, with the
+// context error.
+func (sender *Sender) Flush(ctx context.Context) (n int, err error) {
+	return sender.waitPending(ctx)
+}
+
+// Close stop receiving new post and wait until all queued posts has been
+// send to Mattermost or the context `ctx` is done.
+// Any call to Push after Close will return ErrClosed.
+//
+// If the context is done before all posts has been send, all of the
+// outstanding requests will be cancelled and it will return the number of
+// posts that are dropped, with the context error.
+//
+// If the spool is enabled, the posts are already stored in the spool, so
+// Close does not wait for the post that failed temporarily to be retried.
+// The posts that has not been send are kept in the spool, to be send on the
+// next start, and it will return the number of them with ErrSpooled.
+func (sender *Sender) Close(ctx context.Context) (dropped int, err error) {
+	sender.locker.Lock()
+	if !sender.closed {
+		sender.closed = true
+		close(sender.chanPost)
+		if sender.chanStop != nil {
+			close(sender.chanStop)
+		}
+	}
+	sender.locker.Unlock()
+
+	dropped, err = sender.waitPending(ctx)
+	sender.cancel()
+
+	if sender.spool != nil {
+		<-sender.spoolDone
+		_ = sender.spool.close()
+	}
+
+	return dropped, err
+}
+
+// endpoint return the endpoint of post destination.
+func (sender *Sender) endpoint(post *Post) string {
+	if len(post.Endpoint) > 0 {
+		return post.Endpoint
+	}
+	return sender.opts.Endpoint
+}
+
+// send will send the post to Mattermost.
+// If the request failed temporarily, it will be retried based on the
+// RetryPolicy in Options.
+//
+// On success it will return the HTTP response body with nil error.
+// On fail it will return empty response with *Error.
+func (sender *Sender) send(post *Post) (sResBody string, err error) {
+	var (
+		endpoint = sender.endpoint(post)
+		reqBody  []byte
+	)
+
+	reqBody, err = post.Payload.MarshalJSON()
+	if err != nil {
+		return "", newError(endpoint, 0, err)
+	}
+
+	return sender.sendPayload(sender.metricsOf(post), endpoint, reqBody)
+}
+
+// sendPayload send the JSON of post `reqBody` to Mattermost `endpoint`,
+// with retry.
+// The latency, retry, and success are recorded in metrics `m`.
+// On fail it will return *Error with the error from the last attempt.
+func (sender *Sender) sendPayload(m *metrics, endpoint string, reqBody []byte) (
+	sResBody string, err error,
+) {
+	var (
+		policy     = &sender.opts.Retry
+		retryAfter time.Duration
+		start      time.Time
+		attempt    int
+		ok         bool
+	)
+
+	for {
+		if attempt > 0 {
+			m.add(&m.retried, 1)
+		}
+		attempt++
+
+		start = time.Now()
+		sResBody, err = sender.post(endpoint, reqBody)
+		m.observe(time.Since(start))
+		if err == nil {
+			m.add(&m.sent, 1)
+			return sResBody, nil
+		}
+		if attempt >= policy.MaxAttempts {
+			break
+		}
+
+		ok, retryAfter = isRetryable(err)
+		if !ok {
+			break
+		}
+
+		if !sender.sleep(policy.backoff(attempt, retryAfter)) {
+			break
+		}
+	}
+
+	return "", newError(endpoint, attempt, err)
+}
+
+// post send the request body `reqBody` to Mattermost `endpoint`.
+// If the rate limit is enabled, it will wait until the request is allowed.
+// Non-2xx response will be returned as *webhook.StatusError.
+func (sender *Sender) post(endpoint string, reqBody []byte) (
+	sResBody string, err error,
+) {
+	if sender.limiter != nil {
+		err = sender.limiter.wait(sender.ctx)
+		if err != nil {
+			return
+		}
+	}
+
+	resBody, err := sender.client.PostRaw(sender.ctx, endpoint, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	return string(resBody), nil
+}
+
+// consumer will consume post from channel `chanPost` to be send to
+// Mattermost, until the channel is closed.
+// There are Options.Workers consumer running concurrently.
+func (sender *Sender) consumer() {
+	for post := range sender.chanPost {
+		_, err := sender.send(post)
+		if err != nil {
+			m := sender.metricsOf(post)
+			m.add(&m.failed, 1)
+			sender.onError(post, err)
+		}
+		sender.donePending(1)
+	}
+}
+
+// spoolConsumer will consume post payload from spool, in order, until the
+// Sender is closed.
+// The post that failed temporarily is retried until success or the Sender
+// is closed; it will be removed from spool only if its success or failed
+// permanently.
+func (sender *Sender) spoolConsumer() {
+	var (
+		policy     = &sender.opts.Retry
+		m          *metrics
+		rec        []byte
+		endpoint   string
+		level      string
+		channel    string
+		payload    []byte
+		retryAfter time.Duration
+		err        error
+		dropped    int
+		attempt    int
+		ok         bool
+	)
+
+	defer close(sender.spoolDone)
+
+	for {
+		rec, dropped, err = sender.spool.next(time.Now())
+		if dropped > 0 {
+			m = sender.metricsUnknown()
+			m.add(&m.dropped, dropped)
+			sender.drop(dropped)
+		}
+		if err != nil {
+			// Failed reading the spool, try again later.
+			attempt++
+			if !sender.sleep(policy.backoff(attempt, 0)) {
+				return
+			}
+			continue
+		}
+		if rec == nil {
+			select {
+			case <-sender.spool.chanNotify:
+				continue
+			case <-sender.ctx.Done():
+				return
+			}
+		}
+
+		endpoint, level, channel, payload = parseSpoolRecord(rec)
+		if len(endpoint) == 0 {
+			endpoint = sender.opts.Endpoint
+		}
+		m = _metrics.get(metricsKey{
+			level:    level,
+			endpoint: endpoint,
+			channel:  channel,
+		})
+
+		_, err = sender.sendPayload(m, endpoint, payload)
+		if sender.ctx.Err() != nil {
+			// The Sender has been closed, keep the post in
+			// spool.
+			return
+		}
+		if err != nil {
+			ok, retryAfter = isRetryable(err)
+			if ok {
+				attempt++
+				if !sender.sleep(policy.backoff(attempt, retryAfter)) {
+					return
+				}
+				m.add(&m.retried, 1)
+				continue
+			}
+			m.add(&m.failed, 1)
+			sender.onError(&Post{
+				Payload:  json.RawMessage(append([]byte(nil), payload...)),
+				Endpoint: endpoint,
+				Channel:  channel,
+				Level:    parseLevel(level),
+			}, err)
+		}
+		attempt = 0
+
+		dropped, _ = sender.spool.commit()
+		sender.donePending(dropped)
+	}
+}
+
+// onError call the OnError in Options, if its set, with the post that
+// failed to be send.
+func (sender *Sender) onError(post *Post, err error) {
+	if sender.opts.OnError != nil {
+		sender.opts.OnError(post, err)
+	}
+}
+
+// sleep wait for duration `d`.
+// It will return false if the Sender has been closed before `d`.
+func (sender *Sender) sleep(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-sender.chanStop:
+		return false
+	case <-sender.ctx.Done():
+		return false
+	}
+}
+
+// addPending increment the number of posts that are queued or being
+// send.
+func (sender *Sender) addPending() {
+	sender.pendingLocker.Lock()
+	sender.pending++
+	sender.pendingLocker.Unlock()
+}
+
+// donePending decrement the number of pending posts by `n` and notify the
+// waiter if there is no more post to be send.
+func (sender *Sender) donePending(n int) {
+	if n == 0 {
+		return
+	}
+	sender.pendingLocker.Lock()
+	sender.pending -= n
+	if sender.pending == 0 && sender.chanIdle != nil {
+		close(sender.chanIdle)
+		sender.chanIdle = nil
+	}
+	sender.pendingLocker.Unlock()
+}
+
+// waitPending wait until all pending posts has been send or the context
+// `ctx` is done.
+// It will return the number of posts that has not been send yet and the
+// context error.
+// If the spool consumer has been stopped, it will return immediately with
+// ErrSpooled.
+func (sender *Sender) waitPending(ctx context.Context) (n int, err error) {
+	var chanIdle chan struct{}
+
+	for {
+		sender.pendingLocker.Lock()
+		n = sender.pending
+		if n == 0 {
+			sender.pendingLocker.Unlock()
+			return 0, nil
+		}
+		if sender.chanIdle == nil {
+			sender.chanIdle = make(chan struct{})
+		}
+		chanIdle = sender.chanIdle
+		sender.pendingLocker.Unlock()
+
+		select {
+		case <-chanIdle:
+		case <-sender.spoolDone:
+			sender.pendingLocker.Lock()
+			n = sender.pending
+			sender.pendingLocker.Unlock()
+			return n, ErrSpooled
+		case <-ctx.Done():
+			sender.pendingLocker.Lock()
+			n = sender.pending
+			sender.pendingLocker.Unlock()
+			return n, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+// newTestServer create HTTP server that send each request body into
+// `chanBody`.
+func newTestServer(t *testing.T) (srv *httptest.Server, chanBody chan string) {
+	chanBody = make(chan string, 30)
+
+	srv = httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			chanBody <- string(body)
+		}))
+	t.Cleanup(srv.Close)
+
+	return srv, chanBody
+}
+
+// newTestSender create new Sender that is closed when the test finished.
+func newTestSender(t *testing.T, opts Options) (sender *Sender) {
+	sender, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = sender.Close(context.Background())
+	})
+	return sender
+}
+
+// newTextPost create new Post with `text` as payload.
+func newTextPost(level Level, text string) (post *Post) {
+	return &Post{
+		Payload: webhook.Payload{Text: text},
+		Level:   level,
+	}
+}
+
+func TestSenderFlush(t *testing.T) {
+	srv, chanBody := newTestServer(t)
+
+	sender := newTestSender(t, Options{
+		Endpoint: srv.URL,
+	})
+
+	for _, text := range []string{"one", "two", "three"} {
+		err := sender.Push(newTextPost(LevelInfo, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := sender.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, 0, n, true)
+	assert(t, 3, len(chanBody), true)
+}
+
+func TestSenderClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+	t.Cleanup(srv.Close)
+
+	sender, err := New(Options{
+		Endpoint: srv.URL,
+		Workers:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"one", "two", "three"} {
+		err = sender.Push(newTextPost(LevelInfo, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+
+	n, err := sender.Close(ctx)
+
+	assert(t, context.DeadlineExceeded, err, true)
+	assert(t, true, n > 0, true)
+
+	err = sender.Push(newTextPost(LevelInfo, "after close"))
+	assert(t, ErrClosed, err, true)
+	assert(t, false, sender.TryPush(newTextPost(LevelInfo, "after close")), true)
+}
+
+func TestLevelString(t *testing.T) {
+	tests := []struct {
+		exp string
+		in  Level
+	}{
+		{in: LevelPanic, exp: "panic"},
+		{in: LevelWarning, exp: "warning"},
+		{in: LevelTrace, exp: "trace"},
+		{in: LevelTrace + 1, exp: LevelUnknown},
+	}
+
+	for _, test := range tests {
+		t.Log(test.exp)
+		assert(t, test.exp, test.in.String(), true)
+		if test.exp != LevelUnknown {
+			assert(t, test.in, parseLevel(test.exp), true)
+		}
+	}
+}
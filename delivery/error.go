@@ -0,0 +1,110 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// redacted define the text that replace the webhook token in endpoint.
+const redacted = "xxxxx"
+
+// Error define an error when the post failed to be send to Mattermost,
+// after all attempts.
+//
+// The Endpoint and the error message does not contains the webhook token,
+// so it is safe to be logged.
+type Error struct {
+	// Err define the error from the last attempt, for example
+	// *webhook.StatusError or network error.
+	Err error
+
+	// Endpoint define the destination URL with the webhook token
+	// replaced with "xxxxx".
+	Endpoint string
+
+	// Status define the HTTP status of last response, for example
+	// "400 Bad Request", or empty if the request is failed before
+	// receiving response.
+	Status string
+
+	// Body define the body of last response.
+	Body string
+
+	// StatusCode define the HTTP status code of last response, or zero
+	// if the request is failed before receiving response.
+	StatusCode int
+
+	// Attempt define the number of attempts that has been made,
+	// including the first one.
+	Attempt int
+}
+
+// newError create new Error from the error `err` of the n-th `attempt`
+// to send post to `endpoint`.
+func newError(endpoint string, attempt int, err error) (derr *Error) {
+	derr = &Error{
+		Endpoint: redactEndpoint(endpoint),
+		Attempt:  attempt,
+		Err:      err,
+	}
+
+	var serr *webhook.StatusError
+	if errors.As(err, &serr) {
+		derr.Status = serr.Status
+		derr.Body = serr.Body
+		derr.StatusCode = serr.Code
+	}
+
+	// The URL in error from http.Client contains the token.
+	uerr, ok := err.(*url.Error)
+	if ok {
+		copied := *uerr
+		copied.URL = derr.Endpoint
+		derr.Err = &copied
+	}
+
+	return derr
+}
+
+func (derr *Error) Error() string {
+	return fmt.Sprintf("%s: attempt %d: %s", derr.Endpoint, derr.Attempt,
+		derr.Err)
+}
+
+// Unwrap return the error from the last attempt.
+func (derr *Error) Unwrap() error {
+	return derr.Err
+}
+
+// redactEndpoint return the `endpoint` with the webhook token, the last
+// path segment, the query, and the password replaced with "xxxxx".
+func redactEndpoint(endpoint string) string {
+	if len(endpoint) == 0 {
+		return ""
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return redacted
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	x := strings.LastIndexByte(path, '/')
+	if x >= 0 && x < len(path)-1 {
+		u.Path = path[:x+1] + redacted
+		u.RawPath = ""
+	}
+	if len(u.RawQuery) > 0 {
+		u.RawQuery = redacted
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricsVarName define the name of expvar variable that contains the
+// metrics of all senders, see MetricsSnapshot.
+const MetricsVarName = "mattermost_hooks"
+
+// _latencyBuckets define the upper bounds, in seconds, of latency
+// histogram.
+var _latencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+}
+
+// _metrics contains the delivery metrics of all senders.
+var _metrics = &metricsRegistry{
+	entries: make(map[metricsKey]*metrics),
+}
+
+func init() {
+	expvar.Publish(MetricsVarName, expvar.Func(func() interface{} {
+		return MetricsSnapshot()
+	}))
+}
+
+// Metrics contains the number of messages and the latency of sending
+// message for single level and destination.
+//
+// The message is the post send to Mattermost; multiple log entries that
+// are combined into one post by batch count as one message.
+type Metrics struct {
+	// Level define the level of message, for example "error", or
+	// LevelUnknown.
+	Level string `json:"level"`
+
+	// Endpoint define the destination URL with the webhook token
+	// replaced with "xxxxx".
+	Endpoint string `json:"endpoint"`
+
+	// Channel define the destination channel, or empty for the default
+	// channel of incoming webhook.
+	Channel string `json:"channel"`
+
+	// Latency define the histogram of duration of each request to
+	// Mattermost.
+	// Each attempt is observed separately, so the message that is
+	// retried is observed more than once.
+	Latency Histogram `json:"latency"`
+
+	// Queued define the number of messages that has been queued.
+	Queued int64 `json:"queued"`
+
+	// Sent define the number of messages that has been send
+	// successfully.
+	Sent int64 `json:"sent"`
+
+	// Retried define the number of retry to send the messages.
+	Retried int64 `json:"retried"`
+
+	// Dropped define the number of messages that has been dropped,
+	// because the queue or spool is full, or the message is older than
+	// the spool maximum age.
+	Dropped int64 `json:"dropped"`
+
+	// Failed define the number of messages that failed to be send and
+	// is discarded, the same message that passed to OnError.
+	Failed int64 `json:"failed"`
+}
+
+// Histogram define the distribution of latency, in seconds.
+type Histogram struct {
+	// Buckets define the upper bounds of each bucket.
+	Buckets []float64 `json:"buckets"`
+
+	// Counts define the cumulative number of observations that are
+	// less than or equal to the upper bound in Buckets.
+	Counts []uint64 `json:"counts"`
+
+	// Count define the total number of observations.
+	Count uint64 `json:"count"`
+
+	// Sum define the sum of all observations.
+	Sum float64 `json:"sum"`
+}
+
+// MetricsSnapshot return the current metrics of all senders, sorted by
+// endpoint, channel, and level.
+func MetricsSnapshot() (list []Metrics) {
+	return _metrics.snapshot()
+}
+
+// metricsKey define the labels of metrics.
+// The endpoint is not redacted yet, to minimize the work on each message.
+type metricsKey struct {
+	level    string
+	endpoint string
+	channel  string
+}
+
+// metrics contains the counters and latency for single metricsKey.
+type metrics struct {
+	// latency contains the number of observations in each bucket,
+	// not cumulative, where the last one is for +Inf.
+	latency    []uint64
+	latencySum float64
+
+	queued  int64
+	sent    int64
+	retried int64
+	dropped int64
+	failed  int64
+
+	sync.Mutex
+}
+
+// metricsRegistry contains the metrics indexed by its key.
+type metricsRegistry struct {
+	entries map[metricsKey]*metrics
+	sync.Mutex
+}
+
+// get return the metrics for `key`, create new one if its not exist.
+func (reg *metricsRegistry) get(key metricsKey) (m *metrics) {
+	reg.Lock()
+	m = reg.entries[key]
+	if m == nil {
+		m = &metrics{
+			latency: make([]uint64, len(_latencyBuckets)+1),
+		}
+		reg.entries[key] = m
+	}
+	reg.Unlock()
+	return m
+}
+
+// snapshot return copy of all metrics.
+// The metrics with the same labels after the endpoint redacted are
+// merged.
+func (reg *metricsRegistry) snapshot() (list []Metrics) {
+	var (
+		merged = make(map[metricsKey]*Metrics)
+		key    metricsKey
+	)
+
+	reg.Lock()
+	for k, m := range reg.entries {
+		key = metricsKey{
+			level:    k.level,
+			endpoint: redactEndpoint(k.endpoint),
+			channel:  k.channel,
+		}
+		out := merged[key]
+		if out == nil {
+			out = &Metrics{
+				Level:    key.level,
+				Endpoint: key.endpoint,
+				Channel:  key.channel,
+				Latency: Histogram{
+					Buckets: append([]float64(nil), _latencyBuckets...),
+					Counts:  make([]uint64, len(_latencyBuckets)),
+				},
+			}
+			merged[key] = out
+		}
+		m.addTo(out)
+	}
+	reg.Unlock()
+
+	list = make([]Metrics, 0, len(merged))
+	for _, m := range merged {
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(x, y int) bool {
+		if list[x].Endpoint != list[y].Endpoint {
+			return list[x].Endpoint < list[y].Endpoint
+		}
+		if list[x].Channel != list[y].Channel {
+			return list[x].Channel < list[y].Channel
+		}
+		return list[x].Level < list[y].Level
+	})
+	return list
+}
+
+// addTo add the counters and latency into `out`.
+func (m *metrics) addTo(out *Metrics) {
+	m.Lock()
+	defer m.Unlock()
+
+	out.Queued += m.queued
+	out.Sent += m.sent
+	out.Retried += m.retried
+	out.Dropped += m.dropped
+	out.Failed += m.failed
+
+	var cumulative uint64
+	for x := range out.Latency.Counts {
+		cumulative += m.latency[x]
+		out.Latency.Counts[x] += cumulative
+	}
+	out.Latency.Count += cumulative + m.latency[len(m.latency)-1]
+	out.Latency.Sum += m.latencySum
+}
+
+// add increment the counter `c` by `n`.
+func (m *metrics) add(c *int64, n int) {
+	if n == 0 {
+		return
+	}
+	m.Lock()
+	*c += int64(n)
+	m.Unlock()
+}
+
+// observe record the latency `d` of one request.
+func (m *metrics) observe(d time.Duration) {
+	var (
+		sec = d.Seconds()
+		x   = sort.SearchFloat64s(_latencyBuckets, sec)
+	)
+
+	m.Lock()
+	m.latency[x]++
+	m.latencySum += sec
+	m.Unlock()
+}
+
+// metricsOf return the metrics for `post`.
+func (sender *Sender) metricsOf(post *Post) *metrics {
+	return _metrics.get(metricsKey{
+		level:    post.Level.String(),
+		endpoint: sender.endpoint(post),
+		channel:  post.Channel,
+	})
+}
+
+// metricsUnknown return the metrics for post that its level and
+// destination are not known, using the Endpoint and Channel in Options.
+func (sender *Sender) metricsUnknown() *metrics {
+	return _metrics.get(metricsKey{
+		level:    LevelUnknown,
+		endpoint: sender.opts.Endpoint,
+		channel:  sender.opts.Channel,
+	})
+}
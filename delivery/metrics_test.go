@@ -0,0 +1,161 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+// metricsOfEndpoint return the metrics snapshot that has the `endpoint`,
+// without the latency.
+func metricsOfEndpoint(endpoint string) (list []Metrics) {
+	for _, m := range MetricsSnapshot() {
+		if m.Endpoint != endpoint {
+			continue
+		}
+		m.Latency = Histogram{}
+		list = append(list, m)
+	}
+	return list
+}
+
+func TestSenderMetrics(t *testing.T) {
+	srv, _ := newRetryServer(t, []int{503, 400}, nil)
+
+	sender := newTestSender(t, Options{
+		Endpoint: srv.URL + "/hooks/token",
+		Retry: RetryPolicy{
+			MaxAttempts: 2,
+			BaseBackoff: time.Millisecond,
+		},
+		Workers: 1,
+	})
+
+	// The first post is retried after 503 and then failed with 400,
+	// the next posts are send successfully.
+	for _, lvl := range []Level{LevelError, LevelInfo, LevelInfo} {
+		post := newTextPost(lvl, "test")
+		post.Channel = "ops"
+
+		err := sender.Push(post)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = sender.Flush(context.Background())
+	}
+
+	endpoint := srv.URL + "/hooks/xxxxx"
+	exp := []Metrics{{
+		Level:    "error",
+		Endpoint: endpoint,
+		Channel:  "ops",
+		Queued:   1,
+		Retried:  1,
+		Failed:   1,
+	}, {
+		Level:    "info",
+		Endpoint: endpoint,
+		Channel:  "ops",
+		Queued:   2,
+		Sent:     2,
+	}}
+	assert(t, exp, metricsOfEndpoint(endpoint), true)
+
+	// The latency is observed on each attempt: two for the first post
+	// and one for each of the next posts.
+	var latencyCount uint64
+	for _, m := range MetricsSnapshot() {
+		if m.Endpoint == endpoint {
+			latencyCount += m.Latency.Count
+		}
+	}
+	assert(t, uint64(4), latencyCount, true)
+}
+
+func TestSenderMetricsDropped(t *testing.T) {
+	sender := newTestQueueSender(Options{
+		Endpoint:  "http://127.0.0.1/hooks/dropped",
+		QueueSize: 1,
+		Overflow:  OverflowDropOldest,
+	})
+
+	for _, text := range []string{"m1", "m2"} {
+		err := sender.Push(newTextPost(LevelWarning, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := []Metrics{{
+		Level:    "warning",
+		Endpoint: "http://127.0.0.1/hooks/xxxxx",
+		Queued:   2,
+		Dropped:  1,
+	}}
+	assert(t, exp, metricsOfEndpoint("http://127.0.0.1/hooks/xxxxx"), true)
+}
+
+func TestMetricsObserve(t *testing.T) {
+	var (
+		reg = &metricsRegistry{
+			entries: make(map[metricsKey]*metrics),
+		}
+		m = reg.get(metricsKey{level: "info", endpoint: "http://a/hooks/1"})
+	)
+
+	m.observe(3 * time.Millisecond)
+	m.observe(40 * time.Millisecond)
+	m.observe(time.Minute)
+
+	// The same endpoint after redacted are merged.
+	m = reg.get(metricsKey{level: "info", endpoint: "http://a/hooks/2"})
+	m.observe(40 * time.Millisecond)
+
+	list := reg.snapshot()
+	assert(t, 1, len(list), true)
+
+	got := list[0].Latency
+	exp := Histogram{
+		Buckets: _latencyBuckets,
+		Counts:  []uint64{1, 1, 1, 3, 3, 3, 3, 3, 3, 3, 3, 3},
+		Count:   4,
+		Sum:     got.Sum,
+	}
+	assert(t, exp, got, true)
+	assert(t, true, got.Sum > 60 && got.Sum < 60.1, true)
+}
+
+func TestMetricsExpvar(t *testing.T) {
+	m := _metrics.get(metricsKey{
+		level:    "debug",
+		endpoint: "http://127.0.0.1/hooks/expvar",
+	})
+	m.add(&m.sent, 1)
+
+	v := expvar.Get(MetricsVarName)
+	if v == nil {
+		t.Fatalf("expvar %s is not published", MetricsVarName)
+	}
+
+	var list []Metrics
+	err := json.Unmarshal([]byte(v.String()), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, got := range list {
+		if got.Endpoint == "http://127.0.0.1/hooks/xxxxx" &&
+			got.Level == "debug" {
+			assert(t, int64(1), got.Sent, true)
+			found = true
+		}
+	}
+	assert(t, true, found, true)
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"net/http"
+	"time"
+)
+
+// Options define the configuration for creating new Sender.
+type Options struct {
+	// HTTPClient define the HTTP client that will be used to send the
+	// post to Mattermost.
+	// If its nil, each Sender will create their own HTTP client.
+	HTTPClient *http.Client
+
+	// DropReport define the function to create the post that report
+	// the number of dropped posts, send every DropReportInterval.
+	// The `text` contains the number of posts that are dropped since
+	// the last report and the `total` is the value of Dropped.
+	// If its nil or return nil, no report is send.
+	DropReport func(text string, total int64) *Post
+
+	// OnError define the function that will be called when the post
+	// failed to be send to Mattermost and is discarded, after all
+	// attempts in Retry.
+	// The error is *Error.
+	// If the spool is enabled, it is called only if the post is failed
+	// permanently, since the post that failed temporarily is kept in
+	// spool, and the Payload is json.RawMessage read from spool.
+	//
+	// The function is called by the worker routine, so it should not
+	// block and should be safe to be called concurrently.
+	OnError func(post *Post, err error)
+
+	// Endpoint define the URL of Mattermost incoming webhook, for post
+	// that does not have Endpoint.
+	Endpoint string
+
+	// Channel define the default channel of post, used only as label
+	// of metrics for post that the channel is not known, for example
+	// the post that are dropped from spool.
+	Channel string
+
+	// Spool define the options for storing the queued posts in files.
+	// The default is the posts are queued in memory.
+	Spool SpoolOptions
+
+	// Retry define the policy to retry sending post when the request
+	// to Mattermost failed temporarily.
+	// The default is no retry.
+	Retry RetryPolicy
+
+	// RateLimit define the maximum number of requests per second to
+	// Mattermost.
+	// Default to 10, follow the default Mattermost rate limit.
+	// Set to negative value to disable it.
+	RateLimit float64
+
+	// RateBurst define the maximum number of requests that can be send
+	// at once, before limited by RateLimit.
+	// Default to 100.
+	RateBurst int
+
+	// Workers define the maximum number of concurrent requests to
+	// Mattermost.
+	// Default to 3.
+	// If the spool is enabled, the posts are always send by single
+	// worker to keep the order.
+	Workers int
+
+	// QueueSize define the maximum number of posts that can be queued
+	// in memory before send.
+	// Default to 30.
+	QueueSize int
+
+	// Overflow define the policy when the queue is full.
+	// Default to OverflowBlock.
+	Overflow OverflowPolicy
+
+	// OverflowLevel define the level for OverflowDropBelow policy.
+	// When the queue is full, the post with level less severe than
+	// OverflowLevel will be dropped.
+	OverflowLevel Level
+
+	// DropReportInterval define the interval to send the number of
+	// dropped posts to Mattermost, if any, using DropReport.
+	// Default to 1 minute.
+	// Set to negative value to disable it.
+	DropReportInterval time.Duration
+}
+
+// setDefault set the default value for optional fields.
+func (opts *Options) setDefault() {
+	if opts.RateLimit == 0 {
+		opts.RateLimit = defRateLimit
+	}
+	if opts.RateBurst <= 0 {
+		opts.RateBurst = defRateBurst
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defWorkers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defQueueSize
+	}
+	if opts.DropReportInterval == 0 {
+		opts.DropReportInterval = defDropReportInterval
+	}
+	opts.Retry.init()
+	opts.Spool.init()
+}
+
+// newHTTPClient create new HTTP client with reusable transport.
+func newHTTPClient() *http.Client {
+	var httpTr = &http.Transport{
+		MaxIdleConns:       3,
+		IdleConnTimeout:    time.Minute,
+		DisableCompression: false,
+	}
+
+	return &http.Client{
+		Transport: httpTr,
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defQueueSize          = 30
+	defDropReportInterval = time.Minute
+)
+
+// OverflowPolicy define what Push will do when the queue is full.
+type OverflowPolicy int
+
+// List of overflow policy.
+const (
+	// OverflowBlock block the Push until the queue has free space.
+	// This is the default policy.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drop the new post.
+	OverflowDropNewest
+
+	// OverflowDropOldest drop the oldest post in the queue to make
+	// space for the new post.
+	OverflowDropOldest
+
+	// OverflowDropBelow drop the new post if its level is less severe
+	// than the OverflowLevel in Options, otherwise block.
+	OverflowDropBelow
+)
+
+// Dropped return the total number of posts that has been dropped, either
+// because the queue is full, the spool is full, or the post is older than
+// the spool maximum age.
+func (sender *Sender) Dropped() (n int64) {
+	sender.droppedLocker.Lock()
+	n = sender.dropped
+	sender.droppedLocker.Unlock()
+	return n
+}
+
+// drop mark `n` pending posts as dropped.
+func (sender *Sender) drop(n int) {
+	if n == 0 {
+		return
+	}
+	sender.droppedLocker.Lock()
+	sender.dropped += int64(n)
+	sender.droppedLocker.Unlock()
+
+	sender.donePending(n)
+}
+
+// pushOverflow push the post into queue that is full, based on the
+// overflow policy.
+func (sender *Sender) pushOverflow(post *Post) {
+	switch sender.opts.Overflow {
+	case OverflowDropNewest:
+		sender.dropPost(post)
+		return
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case sender.chanPost <- post:
+				m := sender.metricsOf(post)
+				m.add(&m.queued, 1)
+				return
+			default:
+			}
+			select {
+			case old := <-sender.chanPost:
+				sender.dropPost(old)
+			default:
+			}
+		}
+
+	case OverflowDropBelow:
+		if post.Level > sender.opts.OverflowLevel {
+			sender.dropPost(post)
+			return
+		}
+	}
+
+	sender.chanPost <- post
+
+	m := sender.metricsOf(post)
+	m.add(&m.queued, 1)
+}
+
+// dropPost mark the pending `post` as dropped.
+func (sender *Sender) dropPost(post *Post) {
+	m := sender.metricsOf(post)
+	m.add(&m.dropped, 1)
+	sender.drop(1)
+}
+
+// reportDropped periodically send the number of dropped posts, if any,
+// using the post from DropReport in Options, until the Sender is closed.
+func (sender *Sender) reportDropped() {
+	var (
+		interval = sender.opts.DropReportInterval
+		ticker   = time.NewTicker(interval)
+		last     int64
+		dropped  int64
+		post     *Post
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-sender.ctx.Done():
+			return
+		}
+
+		dropped = sender.Dropped()
+		if dropped == last {
+			continue
+		}
+
+		post = sender.opts.DropReport(fmt.Sprintf(
+			"%d log messages were dropped in the last %s",
+			dropped-last, interval), dropped)
+		if post == nil {
+			continue
+		}
+
+		// If the queue is still full, report it on the next
+		// interval.
+		if sender.TryPush(post) {
+			last = dropped
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// newTestQueueSender create Sender without consumer, so the queue can be
+// inspected.
+func newTestQueueSender(opts Options) (sender *Sender) {
+	opts.setDefault()
+
+	sender = &Sender{
+		opts:     opts,
+		chanPost: make(chan *Post, opts.QueueSize),
+	}
+
+	return sender
+}
+
+// queuedPosts return the text of posts in the queue.
+func queuedPosts(sender *Sender) (got []string) {
+	for {
+		select {
+		case post := <-sender.chanPost:
+			got = append(got, post.Payload.(webhook.Payload).Text)
+		default:
+			return got
+		}
+	}
+}
+
+func TestSenderOverflow(t *testing.T) {
+	tests := []struct {
+		desc       string
+		exp        []string
+		in         []*Post
+		policy     OverflowPolicy
+		expDropped int64
+	}{
+		{
+			desc:   "With drop newest",
+			policy: OverflowDropNewest,
+			in: []*Post{
+				newTextPost(LevelInfo, "m1"),
+				newTextPost(LevelInfo, "m2"),
+				newTextPost(LevelError, "m3"),
+			},
+			exp:        []string{"m1", "m2"},
+			expDropped: 1,
+		},
+		{
+			desc:   "With drop oldest",
+			policy: OverflowDropOldest,
+			in: []*Post{
+				newTextPost(LevelInfo, "m1"),
+				newTextPost(LevelInfo, "m2"),
+				newTextPost(LevelInfo, "m3"),
+				newTextPost(LevelError, "m4"),
+			},
+			exp:        []string{"m3", "m4"},
+			expDropped: 2,
+		},
+		{
+			desc:   "With drop below warning",
+			policy: OverflowDropBelow,
+			in: []*Post{
+				newTextPost(LevelInfo, "m1"),
+				newTextPost(LevelError, "m2"),
+				newTextPost(LevelInfo, "m3"),
+				newTextPost(LevelDebug, "m4"),
+			},
+			exp:        []string{"m1", "m2"},
+			expDropped: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		sender := newTestQueueSender(Options{
+			QueueSize:     2,
+			Overflow:      test.policy,
+			OverflowLevel: LevelWarning,
+		})
+
+		for _, post := range test.in {
+			err := sender.Push(post)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		assert(t, test.exp, queuedPosts(sender), true)
+		assert(t, test.expDropped, sender.Dropped(), true)
+	}
+}
+
+func TestSenderOverflowDropBelowBlock(t *testing.T) {
+	sender := newTestQueueSender(Options{
+		QueueSize:     1,
+		Overflow:      OverflowDropBelow,
+		OverflowLevel: LevelWarning,
+	})
+
+	_ = sender.Push(newTextPost(LevelInfo, "m1"))
+
+	// The error post should block until the queue has free space.
+	chanDone := make(chan struct{})
+	go func() {
+		_ = sender.Push(newTextPost(LevelError, "m2"))
+		close(chanDone)
+	}()
+
+	select {
+	case <-chanDone:
+		t.Fatal("expecting Push to block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	post := <-sender.chanPost
+	assert(t, "m1", post.Payload.(webhook.Payload).Text, true)
+	<-chanDone
+	assert(t, []string{"m2"}, queuedPosts(sender), true)
+	assert(t, int64(0), sender.Dropped(), true)
+}
+
+func TestSenderReportDropped(t *testing.T) {
+	srv, chanBody := newTestServer(t)
+
+	sender := newTestSender(t, Options{
+		Endpoint:           srv.URL,
+		DropReportInterval: 20 * time.Millisecond,
+		DropReport: func(text string, total int64) *Post {
+			return newTextPost(LevelWarning, text)
+		},
+	})
+
+	sender.addPending()
+	sender.addPending()
+	sender.addPending()
+	sender.drop(3)
+
+	exp := `{"text":"3 log messages were dropped in the last 20ms"}`
+
+	assert(t, exp, <-chanBody, true)
+	assert(t, int64(3), sender.Dropped(), true)
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import "encoding/json"
+
+// Level define the severity of post, where the lower value is more
+// severe.
+// The value and name of each level are the same as logrus.Level, so the
+// metrics from all loggers use the same labels.
+type Level uint32
+
+// List of post level, from the most severe.
+const (
+	LevelPanic Level = iota
+	LevelFatal
+	LevelError
+	LevelWarning
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+// LevelUnknown define the level label of metrics for post that has been
+// dropped from spool, where the post level is not known.
+const LevelUnknown = "unknown"
+
+// _levelNames contains the name of each Level.
+var _levelNames = []string{
+	"panic",
+	"fatal",
+	"error",
+	"warning",
+	"info",
+	"debug",
+	"trace",
+}
+
+// String return the name of level, for example "warning".
+func (lvl Level) String() string {
+	if int(lvl) < len(_levelNames) {
+		return _levelNames[lvl]
+	}
+	return LevelUnknown
+}
+
+// parseLevel return the Level from its `name`.
+// If the name is unknown, it will return LevelTrace.
+func parseLevel(name string) Level {
+	for x, v := range _levelNames {
+		if v == name {
+			return Level(x)
+		}
+	}
+	return LevelTrace
+}
+
+// Post define the content and destination of message that will be send
+// to Mattermost.
+type Post struct {
+	// Payload define the content of post, for example webhook.Payload.
+	// It is converted into JSON when the post is send, or when its
+	// stored in spool.
+	Payload json.Marshaler
+
+	// Endpoint define the URL of Mattermost incoming webhook.
+	// If its empty, the Endpoint in Options is used.
+	Endpoint string
+
+	// Channel define the channel of post, used only as label of
+	// metrics.
+	// The channel where the post is send is defined in Payload.
+	Channel string
+
+	// Level define the severity of post, used by OverflowDropBelow and
+	// as label of metrics.
+	Level Level
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// The default rate limit follow the default Mattermost rate limit
+	// settings, 10 requests per second with maximum burst 100.
+	defRateLimit = 10
+	defRateBurst = 100
+
+	defWorkers = 3
+)
+
+// rateLimiter limit the number of requests using token bucket algorithm.
+//
+// The bucket is filled with `rate` tokens per second, up to `burst`
+// tokens.
+// Each request take one token from bucket, or wait until the token is
+// available.
+type rateLimiter struct {
+	last   time.Time
+	rate   float64
+	burst  float64
+	tokens float64
+	locker sync.Mutex
+}
+
+// newRateLimiter create new rateLimiter with full bucket.
+func newRateLimiter(rate float64, burst int) (rl *rateLimiter) {
+	rl = &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	return rl
+}
+
+// reserve take one token from bucket.
+// If the bucket is empty, it will return the duration to wait until the
+// token is available.
+func (rl *rateLimiter) reserve(now time.Time) (delay time.Duration) {
+	rl.locker.Lock()
+	defer rl.locker.Unlock()
+
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+}
+
+// wait block until one token is available or the context `ctx` is done.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := rl.reserve(time.Now())
+		if delay == 0 {
+			return nil
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
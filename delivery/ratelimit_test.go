@@ -0,0 +1,123 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Now()
+	rl := newRateLimiter(10, 2)
+	rl.last = now
+
+	tests := []struct {
+		desc string
+		now  time.Time
+		exp  time.Duration
+	}{
+		{
+			desc: "With full bucket",
+			now:  now,
+		},
+		{
+			desc: "With one token left",
+			now:  now,
+		},
+		{
+			desc: "With empty bucket",
+			now:  now,
+			exp:  100 * time.Millisecond,
+		},
+		{
+			desc: "After refilled",
+			now:  now.Add(100 * time.Millisecond),
+		},
+		{
+			desc: "With refilled up to burst",
+			now:  now.Add(time.Hour),
+		},
+		{
+			desc: "With one token left after refilled",
+			now:  now.Add(time.Hour),
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := rl.reserve(test.now)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	rl := newRateLimiter(1, 1)
+
+	err := rl.wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+
+	err = rl.wait(ctx)
+
+	assert(t, context.DeadlineExceeded, err, true)
+}
+
+func TestSenderWorkers(t *testing.T) {
+	var (
+		locker  sync.Mutex
+		active  int
+		maxSeen int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			locker.Lock()
+			active++
+			if active > maxSeen {
+				maxSeen = active
+			}
+			locker.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			locker.Lock()
+			active--
+			locker.Unlock()
+		}))
+	t.Cleanup(srv.Close)
+
+	sender := newTestSender(t, Options{
+		Endpoint: srv.URL,
+		Workers:  2,
+	})
+
+	for x := 0; x < 10; x++ {
+		err := sender.Push(newTextPost(LevelInfo, "test workers"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := sender.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locker.Lock()
+	assert(t, 2, maxSeen, true)
+	locker.Unlock()
+}
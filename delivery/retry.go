@@ -0,0 +1,119 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+const (
+	defRetryBaseBackoff = 500 * time.Millisecond
+	defRetryMaxBackoff  = 30 * time.Second
+)
+
+// RetryPolicy define how the failed request to Mattermost will be retried.
+//
+// The request will be retried only if the error is temporary: network
+// error, HTTP status 408 (Request Timeout), 429 (Too Many Requests), or
+// 5xx (server error).
+// The other 4xx status, for example 400 when Mattermost unable to parse
+// the payload, is permanent and will not be retried.
+type RetryPolicy struct {
+	// MaxAttempts define the maximum number of attempts to send a
+	// message, including the first one.
+	// Zero or one means no retry.
+	MaxAttempts int
+
+	// BaseBackoff define the delay before the first retry.
+	// The delay will be doubled on each subsequent retry.
+	// Default to 500 milliseconds.
+	BaseBackoff time.Duration
+
+	// MaxBackoff define the maximum delay between retry, including the
+	// delay requested by server in Retry-After.
+	// Default to 30 seconds.
+	MaxBackoff time.Duration
+
+	// Jitter define the fraction of delay, in range of [0, 1], that
+	// will be randomized to avoid several senders retrying at the same
+	// time.
+	// For example, jitter 0.2 with delay 1 second will wait between 800
+	// milliseconds and 1 second.
+	Jitter float64
+}
+
+// init set the default value for RetryPolicy.
+func (policy *RetryPolicy) init() {
+	if policy.BaseBackoff <= 0 {
+		policy.BaseBackoff = defRetryBaseBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = defRetryMaxBackoff
+	}
+	if policy.MaxBackoff < policy.BaseBackoff {
+		policy.MaxBackoff = policy.BaseBackoff
+	}
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	} else if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+}
+
+// backoff return the delay before the next attempt, after the n-th
+// `attempt` has been failed.
+// If the server response with Retry-After, the delay will be at least
+// the `retryAfter` value, but not greater than MaxBackoff, so the
+// worker, Flush, and Close are not blocked by server that request long
+// delay.
+func (policy *RetryPolicy) backoff(attempt int, retryAfter time.Duration) (
+	delay time.Duration,
+) {
+	delay = policy.BaseBackoff
+	for x := 1; x < attempt && delay < policy.MaxBackoff; x++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if policy.Jitter > 0 {
+		delay -= time.Duration(policy.Jitter * rand.Float64() * float64(delay))
+	}
+	if delay < retryAfter {
+		delay = retryAfter
+		if delay > policy.MaxBackoff {
+			delay = policy.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// isRetryable return true if the error `err` from sending message is
+// temporary, with the delay requested by server in Retry-After, if any.
+func isRetryable(err error) (ok bool, retryAfter time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+
+	var serr *webhook.StatusError
+	if !errors.As(err, &serr) {
+		// Network error.
+		return true, 0
+	}
+
+	switch {
+	case serr.Code == http.StatusRequestTimeout,
+		serr.Code == http.StatusTooManyRequests,
+		serr.Code >= 500:
+		return true, serr.RetryAfter
+	}
+	return false, 0
+}
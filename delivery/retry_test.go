@@ -0,0 +1,256 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// newRetryServer create HTTP server that response with each status code in
+// `codes` sequentially, and then with 200 OK.
+// Each response has the `header` set.
+func newRetryServer(t *testing.T, codes []int, header http.Header) (
+	srv *httptest.Server, attempts func() int,
+) {
+	var (
+		locker sync.Mutex
+		n      int
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			locker.Lock()
+			n++
+			x := n
+			locker.Unlock()
+
+			for k, v := range header {
+				res.Header()[k] = v
+			}
+			if x <= len(codes) {
+				res.WriteHeader(codes[x-1])
+				_, _ = res.Write([]byte("failed"))
+				return
+			}
+			_, _ = res.Write([]byte("ok"))
+		}))
+	t.Cleanup(srv.Close)
+
+	attempts = func() int {
+		locker.Lock()
+		defer locker.Unlock()
+		return n
+	}
+
+	return srv, attempts
+}
+
+func TestSenderSendRetry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+
+	tests := []struct {
+		desc        string
+		codes       []int
+		expErr      string
+		expRes      string
+		expAttempts int
+	}{
+		{
+			desc:        "With success",
+			expRes:      "ok",
+			expAttempts: 1,
+		},
+		{
+			desc:        "With server error then success",
+			codes:       []int{503, 500},
+			expRes:      "ok",
+			expAttempts: 3,
+		},
+		{
+			desc:        "With rate limited then success",
+			codes:       []int{429},
+			expRes:      "ok",
+			expAttempts: 2,
+		},
+		{
+			desc:        "With server error exceeding attempts",
+			codes:       []int{502, 502, 502},
+			expErr:      "502 Bad Gateway: failed",
+			expAttempts: 3,
+		},
+		{
+			desc:        "With permanent error",
+			codes:       []int{400, 503},
+			expErr:      "400 Bad Request: failed",
+			expAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		srv, attempts := newRetryServer(t, test.codes, nil)
+
+		sender, err := New(Options{
+			Endpoint: srv.URL,
+			Retry:    policy,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := sender.send(newTextPost(LevelInfo, "test retry"))
+		if err != nil {
+			var derr *Error
+			if !errors.As(err, &derr) {
+				t.Fatalf("expecting *Error, got %T", err)
+			}
+			assert(t, test.expErr, derr.Err.Error(), true)
+			assert(t, test.expAttempts, derr.Attempt, true)
+		}
+
+		assert(t, test.expRes, res, true)
+		assert(t, test.expAttempts, attempts(), true)
+
+		_, _ = sender.Close(context.Background())
+	}
+}
+
+func TestSenderSendRetryAfter(t *testing.T) {
+	header := http.Header{
+		"Retry-After": []string{"1"},
+	}
+	srv, attempts := newRetryServer(t, []int{429}, header)
+
+	sender := newTestSender(t, Options{
+		Endpoint: srv.URL,
+		Retry: RetryPolicy{
+			MaxAttempts: 2,
+			BaseBackoff: time.Millisecond,
+		},
+	})
+
+	start := time.Now()
+
+	_, err := sender.send(newTextPost(LevelInfo, "test retry after"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(start) < time.Second {
+		t.Fatalf("expecting retry after 1s, got %s", time.Since(start))
+	}
+	assert(t, 2, attempts(), true)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+	policy.init()
+
+	tests := []struct {
+		desc       string
+		attempt    int
+		retryAfter time.Duration
+		exp        time.Duration
+	}{
+		{
+			desc:    "After first attempt",
+			attempt: 1,
+			exp:     100 * time.Millisecond,
+		},
+		{
+			desc:    "After third attempt",
+			attempt: 3,
+			exp:     400 * time.Millisecond,
+		},
+		{
+			desc:    "With maximum backoff",
+			attempt: 10,
+			exp:     time.Second,
+		},
+		{
+			desc:       "With Retry-After",
+			attempt:    1,
+			retryAfter: 500 * time.Millisecond,
+			exp:        500 * time.Millisecond,
+		},
+		{
+			desc:       "With Retry-After greater than maximum backoff",
+			attempt:    1,
+			retryAfter: 24 * time.Hour,
+			exp:        time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := policy.backoff(test.attempt, test.retryAfter)
+
+		assert(t, test.exp, got, true)
+	}
+
+	policy.Jitter = 0.5
+	for x := 0; x < 10; x++ {
+		got := policy.backoff(1, 0)
+		if got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("backoff with jitter out of range: %s", got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err           error
+		desc          string
+		exp           bool
+		expRetryAfter time.Duration
+	}{
+		{
+			desc: "With network error",
+			err:  errors.New("connection refused"),
+			exp:  true,
+		},
+		{
+			desc: "With bad request",
+			err:  &webhook.StatusError{Code: 400},
+		},
+		{
+			desc:          "With too many requests",
+			err:           &webhook.StatusError{Code: 429, RetryAfter: time.Second},
+			exp:           true,
+			expRetryAfter: time.Second,
+		},
+		{
+			desc: "With service unavailable",
+			err:  &webhook.StatusError{Code: 503},
+			exp:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got, gotRetryAfter := isRetryable(test.err)
+
+		assert(t, test.exp, got, true)
+		assert(t, test.expRetryAfter, gotRetryAfter, true)
+	}
+}
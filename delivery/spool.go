@@ -0,0 +1,576 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defSpoolMaxSize     = 64 << 20
+	defSpoolSegmentSize = 1 << 20
+
+	spoolSegmentExt = ".seg"
+	spoolCursorName = "cursor"
+
+	// spoolHeaderSize is the size of record header: 4 bytes of payload
+	// length, 4 bytes of CRC-32 checksum of timestamp and payload, and
+	// 8 bytes of timestamp in Unix nanoseconds.
+	spoolHeaderSize = 16
+)
+
+// SpoolOptions define the options for durable, file-backed queue.
+//
+// When the spool is enabled, each message payload is appended into
+// segment files inside the Dir, and send to Mattermost in order.
+// The message that failed to be send temporarily will be retried until
+// success, instead of dropped.
+// The messages that has not been send when the Sender closed will be
+// replayed on the next start.
+type SpoolOptions struct {
+	// Dir define the directory where the spool files will be stored.
+	// If its empty, the spool is disabled and the messages are queued in
+	// memory.
+	Dir string
+
+	// MaxSize define the maximum total size of spool files, in bytes.
+	// If the total size exceed MaxSize, the oldest segment will be
+	// removed.
+	// Default to 64 MiB.
+	MaxSize int64
+
+	// MaxAge define the maximum age of message in spool.
+	// The message older than MaxAge will be dropped instead of send.
+	// Default to zero, no limit.
+	MaxAge time.Duration
+}
+
+func (opts *SpoolOptions) init() {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defSpoolMaxSize
+	}
+}
+
+// newSpoolRecord create the spool record from message `endpoint`,
+// `level`, `channel`, and its JSON `payload`.
+// The endpoint, level, and channel are separated by tab, and the header
+// and payload are separated by new line.
+// The empty endpoint means the record is send to the current
+// Options.Endpoint.
+// The level and channel are only used for metrics.
+func newSpoolRecord(endpoint, level, channel string, payload []byte) (
+	rec []byte,
+) {
+	channel = _spoolHeaderEscaper.Replace(channel)
+
+	rec = make([]byte, 0, len(endpoint)+len(level)+len(channel)+3+
+		len(payload))
+	rec = append(rec, endpoint...)
+	rec = append(rec, '\t')
+	rec = append(rec, level...)
+	rec = append(rec, '\t')
+	rec = append(rec, channel...)
+	rec = append(rec, '\n')
+	rec = append(rec, payload...)
+	return rec
+}
+
+// _spoolHeaderEscaper replace the separators in spool record header.
+var _spoolHeaderEscaper = strings.NewReplacer("\t", " ", "\n", " ")
+
+// parseSpoolRecord split the spool record created by newSpoolRecord into
+// message endpoint, level, channel, and its JSON payload.
+func parseSpoolRecord(rec []byte) (
+	endpoint, level, channel string, payload []byte,
+) {
+	header, payload, _ := bytes.Cut(rec, []byte{'\n'})
+
+	endpoint, rest, _ := strings.Cut(string(header), "\t")
+	level, channel, _ = strings.Cut(rest, "\t")
+
+	return endpoint, level, channel, payload
+}
+
+// spoolSegment contains the metadata for single segment file.
+type spoolSegment struct {
+	id   uint64
+	size int64
+}
+
+// spool is a durable queue of message payloads, stored as append-only
+// segment files.
+//
+// The record is appended into the last segment.
+// The record is read from the first segment at offset roff; the reading
+// position is stored in the cursor file after each commit.
+// Once all records in the first segment has been read, the segment file is
+// removed.
+type spool struct {
+	chanNotify chan struct{}
+	fw         *os.File // The last segment, for writing.
+	fr         *os.File // The first segment, for reading.
+	dir        string
+	segments   []*spoolSegment
+
+	maxSize     int64
+	segmentSize int64
+	maxAge      time.Duration
+
+	// size is the total size of all segments.
+	size int64
+
+	// roff is the reading offset in the first segment.
+	roff int64
+
+	// nextSize is the size of record returned by the last next.
+	// It will be set to zero if the record has been committed or
+	// removed because the spool is full.
+	nextSize int64
+
+	// count is the number of records that has not been committed.
+	count int
+
+	locker sync.Mutex
+}
+
+// openSpool open or create the spool inside the directory defined in
+// `opts`.
+// The remaining records from previous spool will be read first.
+func openSpool(opts SpoolOptions) (sp *spool, err error) {
+	var logp = "openSpool"
+
+	sp = &spool{
+		chanNotify:  make(chan struct{}, 1),
+		dir:         opts.Dir,
+		maxSize:     opts.MaxSize,
+		segmentSize: defSpoolSegmentSize,
+		maxAge:      opts.MaxAge,
+	}
+	if sp.segmentSize > sp.maxSize/4 {
+		sp.segmentSize = sp.maxSize / 4
+	}
+
+	err = os.MkdirAll(sp.dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	err = sp.loadSegments()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	err = sp.loadCursor()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	for x, seg := range sp.segments {
+		var off int64
+		if x == 0 {
+			off = sp.roff
+		}
+		sp.count += sp.countRecords(seg, off)
+	}
+
+	// Always write to new segment, so the torn record from previous
+	// process, if any, does not mix with the new records.
+	err = sp.rotate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	return sp, nil
+}
+
+// loadSegments load the list of segment files inside spool directory.
+func (sp *spool) loadSegments() (err error) {
+	var (
+		entries []os.DirEntry
+		fi      os.FileInfo
+		name    string
+		id      uint64
+	)
+
+	entries, err = os.ReadDir(sp.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name = entry.Name()
+		if !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		id, err = strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		fi, err = entry.Info()
+		if err != nil {
+			return err
+		}
+		sp.segments = append(sp.segments, &spoolSegment{
+			id:   id,
+			size: fi.Size(),
+		})
+		sp.size += fi.Size()
+	}
+
+	sort.Slice(sp.segments, func(x, y int) bool {
+		return sp.segments[x].id < sp.segments[y].id
+	})
+
+	return nil
+}
+
+// loadCursor load the reading position from cursor file and remove the
+// segments that has been read.
+func (sp *spool) loadCursor() (err error) {
+	var (
+		content []byte
+		id      uint64
+		off     int64
+	)
+
+	content, err = os.ReadFile(filepath.Join(sp.dir, spoolCursorName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	_, err = fmt.Sscanf(string(content), "%d %d", &id, &off)
+	if err != nil {
+		// Invalid cursor, read from the beginning.
+		return nil
+	}
+
+	for len(sp.segments) > 0 && sp.segments[0].id < id {
+		err = sp.removeFirst()
+		if err != nil {
+			return err
+		}
+	}
+	if len(sp.segments) > 0 && sp.segments[0].id == id {
+		sp.roff = off
+	}
+
+	return nil
+}
+
+// saveCursor store the current reading position into cursor file.
+// The cursor is written and synced into temporary file first, so the
+// cursor file is not corrupted if the process crash while writing it.
+func (sp *spool) saveCursor() (err error) {
+	var (
+		tmp     = filepath.Join(sp.dir, spoolCursorName+".tmp")
+		content = fmt.Sprintf("%d %d\n", sp.segments[0].id, sp.roff)
+		f       *os.File
+	)
+
+	f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(sp.dir, spoolCursorName))
+}
+
+func (sp *spool) segmentPath(seg *spoolSegment) string {
+	return filepath.Join(sp.dir, fmt.Sprintf("%020d%s", seg.id, spoolSegmentExt))
+}
+
+// rotate close the current segment for writing and create new one.
+func (sp *spool) rotate() (err error) {
+	var seg = &spoolSegment{
+		id: 1,
+	}
+
+	if len(sp.segments) > 0 {
+		seg.id = sp.segments[len(sp.segments)-1].id + 1
+	}
+
+	if sp.fw != nil {
+		err = sp.fw.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	sp.fw, err = os.OpenFile(sp.segmentPath(seg),
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	sp.segments = append(sp.segments, seg)
+
+	return nil
+}
+
+// removeFirst remove the first segment.
+func (sp *spool) removeFirst() (err error) {
+	var seg = sp.segments[0]
+
+	if sp.fr != nil {
+		_ = sp.fr.Close()
+		sp.fr = nil
+	}
+
+	err = os.Remove(sp.segmentPath(seg))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	sp.segments = sp.segments[1:]
+	sp.size -= seg.size
+	sp.roff = 0
+	sp.nextSize = 0
+
+	return nil
+}
+
+// readRecord read the record in segment file `f` at offset `off`.
+// It will return io.EOF if there is no complete, valid record at `off`.
+func readRecord(f *os.File, segSize, off int64) (
+	payload []byte, ts int64, recSize int64, err error,
+) {
+	var (
+		header = make([]byte, spoolHeaderSize)
+		length int64
+		sum    uint32
+	)
+
+	if off+spoolHeaderSize > segSize {
+		return nil, 0, 0, io.EOF
+	}
+
+	_, err = f.ReadAt(header, off)
+	if err != nil {
+		return nil, 0, 0, io.EOF
+	}
+
+	length = int64(binary.BigEndian.Uint32(header[0:4]))
+	sum = binary.BigEndian.Uint32(header[4:8])
+	recSize = spoolHeaderSize + length
+
+	if off+recSize > segSize {
+		return nil, 0, 0, io.EOF
+	}
+
+	payload = make([]byte, length)
+	_, err = f.ReadAt(payload, off+spoolHeaderSize)
+	if err != nil {
+		return nil, 0, 0, io.EOF
+	}
+
+	if crc32.Update(crc32.ChecksumIEEE(header[8:]), crc32.IEEETable, payload) != sum {
+		return nil, 0, 0, io.EOF
+	}
+
+	ts = int64(binary.BigEndian.Uint64(header[8:]))
+
+	return payload, ts, recSize, nil
+}
+
+// countRecords count the number of valid records in segment starting from
+// offset `off`.
+func (sp *spool) countRecords(seg *spoolSegment, off int64) (n int) {
+	var (
+		f       *os.File
+		recSize int64
+		err     error
+	)
+
+	f, err = os.Open(sp.segmentPath(seg))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	for {
+		_, _, recSize, err = readRecord(f, seg.size, off)
+		if err != nil {
+			return n
+		}
+		off += recSize
+		n++
+	}
+}
+
+// push append the message payload into the last segment.
+// If the total size of spool exceed the maximum size, the oldest segments
+// will be removed and the number of records in it will be returned as
+// dropped.
+func (sp *spool) push(payload []byte, now time.Time) (dropped int, err error) {
+	var (
+		rec = make([]byte, spoolHeaderSize+len(payload))
+		seg *spoolSegment
+	)
+
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint64(rec[8:16], uint64(now.UnixNano()))
+	copy(rec[spoolHeaderSize:], payload)
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(rec[8:]))
+
+	sp.locker.Lock()
+	defer sp.locker.Unlock()
+
+	seg = sp.segments[len(sp.segments)-1]
+	if seg.size > 0 && seg.size+int64(len(rec)) > sp.segmentSize {
+		err = sp.rotate()
+		if err != nil {
+			return 0, err
+		}
+		seg = sp.segments[len(sp.segments)-1]
+	}
+
+	_, err = sp.fw.Write(rec)
+	if err != nil {
+		return 0, err
+	}
+	err = sp.fw.Sync()
+	if err != nil {
+		return 0, err
+	}
+
+	seg.size += int64(len(rec))
+	sp.size += int64(len(rec))
+	sp.count++
+
+	for sp.size > sp.maxSize && len(sp.segments) > 1 {
+		n := sp.countRecords(sp.segments[0], sp.roff)
+		err = sp.removeFirst()
+		if err != nil {
+			return dropped, err
+		}
+		sp.count -= n
+		dropped += n
+	}
+	if dropped > 0 {
+		err = sp.saveCursor()
+		if err != nil {
+			return dropped, err
+		}
+	}
+
+	select {
+	case sp.chanNotify <- struct{}{}:
+	default:
+	}
+
+	return dropped, nil
+}
+
+// next return the payload of the oldest record that has not been
+// committed.
+// It will return nil payload if there is no record in spool.
+// The record older than maximum age is skipped and returned as dropped.
+//
+// Calling next without commit will return the same record.
+func (sp *spool) next(now time.Time) (payload []byte, dropped int, err error) {
+	var (
+		seg *spoolSegment
+		ts  int64
+	)
+
+	sp.locker.Lock()
+	defer sp.locker.Unlock()
+
+	for {
+		seg = sp.segments[0]
+
+		if sp.fr == nil {
+			sp.fr, err = os.Open(sp.segmentPath(seg))
+			if err != nil {
+				return nil, dropped, err
+			}
+		}
+
+		payload, ts, sp.nextSize, err = readRecord(sp.fr, seg.size, sp.roff)
+		if err != nil {
+			if len(sp.segments) == 1 {
+				// No more record in the last segment.
+				return nil, dropped, nil
+			}
+			// The rest of first segment has been read or corrupted.
+			err = sp.removeFirst()
+			if err != nil {
+				return nil, dropped, err
+			}
+			err = sp.saveCursor()
+			if err != nil {
+				return nil, dropped, err
+			}
+			continue
+		}
+
+		if sp.maxAge > 0 && now.Sub(time.Unix(0, ts)) > sp.maxAge {
+			sp.roff += sp.nextSize
+			sp.count--
+			dropped++
+			continue
+		}
+
+		return payload, dropped, nil
+	}
+}
+
+// commit mark the record returned by the last next as done.
+// It will return the number of committed record, zero if the record has
+// been removed because the spool is full.
+func (sp *spool) commit() (n int, err error) {
+	sp.locker.Lock()
+	defer sp.locker.Unlock()
+
+	if sp.nextSize == 0 {
+		return 0, nil
+	}
+
+	sp.roff += sp.nextSize
+	sp.nextSize = 0
+	sp.count--
+
+	return 1, sp.saveCursor()
+}
+
+// close all the opened segment files.
+func (sp *spool) close() (err error) {
+	sp.locker.Lock()
+	defer sp.locker.Unlock()
+
+	if sp.fr != nil {
+		_ = sp.fr.Close()
+		sp.fr = nil
+	}
+	if sp.fw != nil {
+		err = sp.fw.Close()
+		sp.fw = nil
+	}
+	return err
+}
@@ -0,0 +1,323 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// spoolReadAll read and commit all records in spool.
+func spoolReadAll(t *testing.T, sp *spool, now time.Time) (got []string) {
+	for {
+		payload, _, err := sp.next(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payload == nil {
+			return got
+		}
+		got = append(got, string(payload))
+
+		_, err = sp.commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSpoolReplay(t *testing.T) {
+	var (
+		opts = SpoolOptions{
+			Dir: t.TempDir(),
+		}
+		now = time.Now()
+	)
+	opts.init()
+
+	sp, err := openSpool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range []string{"one", "two", "three"} {
+		_, err = sp.push([]byte(payload), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payload, _, err := sp.next(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "one", string(payload), true)
+
+	_, err = sp.commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Calling next without commit return the same record.
+	payload, _, _ = sp.next(now)
+	assert(t, "two", string(payload), true)
+	payload, _, _ = sp.next(now)
+	assert(t, "two", string(payload), true)
+
+	err = sp.close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the spool, the uncommitted records should be replayed.
+	sp, err = openSpool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.close()
+
+	assert(t, 2, sp.count, true)
+
+	_, err = sp.push([]byte("four"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := spoolReadAll(t, sp, now)
+
+	assert(t, []string{"two", "three", "four"}, got, true)
+	assert(t, 0, sp.count, true)
+}
+
+func TestSpoolMaxSize(t *testing.T) {
+	var (
+		opts = SpoolOptions{
+			Dir:     t.TempDir(),
+			MaxSize: 4 * (spoolHeaderSize + 4),
+		}
+		now     = time.Now()
+		dropped int
+	)
+	opts.init()
+
+	sp, err := openSpool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.close()
+
+	for _, payload := range []string{"0001", "0002", "0003", "0004", "0005", "0006"} {
+		n, err := sp.push([]byte(payload), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dropped += n
+	}
+
+	got := spoolReadAll(t, sp, now)
+
+	assert(t, 2, dropped, true)
+	assert(t, []string{"0003", "0004", "0005", "0006"}, got, true)
+}
+
+func TestSpoolMaxAge(t *testing.T) {
+	var (
+		opts = SpoolOptions{
+			Dir:    t.TempDir(),
+			MaxAge: time.Minute,
+		}
+		now = time.Now()
+	)
+	opts.init()
+
+	sp, err := openSpool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.close()
+
+	_, _ = sp.push([]byte("old"), now.Add(-2*time.Minute))
+	_, _ = sp.push([]byte("new"), now)
+
+	payload, dropped, err := sp.next(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, 1, dropped, true)
+	assert(t, "new", string(payload), true)
+}
+
+func TestSpoolTornRecord(t *testing.T) {
+	var (
+		opts = SpoolOptions{
+			Dir: t.TempDir(),
+		}
+		now = time.Now()
+	)
+	opts.init()
+
+	sp, err := openSpool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = sp.push([]byte("complete"), now)
+	_, _ = sp.push([]byte("torn"), now)
+
+	// Simulate the process crash while writing the last record.
+	seg := sp.segments[len(sp.segments)-1]
+	path := sp.segmentPath(seg)
+	_ = sp.close()
+
+	err = os.Truncate(path, seg.size-2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err = openSpool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.close()
+
+	_, _ = sp.push([]byte("after restart"), now)
+
+	got := spoolReadAll(t, sp, now)
+
+	assert(t, []string{"complete", "after restart"}, got, true)
+}
+
+func TestSenderSpool(t *testing.T) {
+	var dir = t.TempDir()
+
+	// The server is down, all posts should be kept in spool.
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusServiceUnavailable)
+		}))
+
+	sender, err := New(Options{
+		Endpoint: srv.URL,
+		Spool: SpoolOptions{
+			Dir: dir,
+		},
+		Retry: RetryPolicy{
+			BaseBackoff: time.Millisecond,
+			MaxBackoff:  10 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"one", "two", "three"} {
+		err = sender.Push(newTextPost(LevelInfo, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Close should not wait for the posts to be retried, since they
+	// are already stored in spool.
+	n, err := sender.Close(context.Background())
+
+	assert(t, ErrSpooled, err, true)
+	assert(t, 3, n, true)
+
+	srv.Close()
+
+	// Start new Sender with the same spool, the posts should be
+	// replayed in order.
+	srvUp, chanBody := newTestServer(t)
+
+	newTestSender(t, Options{
+		Endpoint: srvUp.URL,
+		Spool: SpoolOptions{
+			Dir: dir,
+		},
+	})
+
+	for _, text := range []string{"one", "two", "three"} {
+		exp := `{"text":"` + text + `"}`
+		assert(t, exp, <-chanBody, true)
+	}
+}
+
+func TestSenderSpoolFlush(t *testing.T) {
+	var (
+		dir           = t.TempDir()
+		srv, chanBody = newTestServer(t)
+	)
+
+	sender := newTestSender(t, Options{
+		Endpoint: srv.URL,
+		Spool: SpoolOptions{
+			Dir: dir,
+		},
+	})
+
+	for _, text := range []string{"one", "two"} {
+		err := sender.Push(newTextPost(LevelInfo, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := sender.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, 0, n, true)
+	assert(t, 2, len(chanBody), true)
+
+	// All of segments should be removed, except the last one.
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	assert(t, 1, len(segments), true)
+}
+
+func TestParseSpoolRecord(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         []byte
+		expPayload string
+		expEnd     string
+		expLevel   string
+		expChannel string
+	}{
+		{
+			desc: "With header",
+			in: newSpoolRecord("http://a/hooks/1", "error", "ops\tdev\n",
+				[]byte(`{"text":"a"}`)),
+			expEnd:     "http://a/hooks/1",
+			expLevel:   "error",
+			expChannel: "ops dev ",
+			expPayload: `{"text":"a"}`,
+		},
+		{
+			desc:       "With empty endpoint and channel",
+			in:         newSpoolRecord("", "info", "", []byte(`{}`)),
+			expLevel:   "info",
+			expPayload: `{}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		endpoint, level, channel, payload := parseSpoolRecord(test.in)
+
+		assert(t, test.expEnd, endpoint, true)
+		assert(t, test.expLevel, level, true)
+		assert(t, test.expChannel, channel, true)
+		assert(t, test.expPayload, string(payload), true)
+	}
+}
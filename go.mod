@@ -4,4 +4,7 @@ go 1.18
 
 require github.com/sirupsen/logrus v1.9.0
 
-require golang.org/x/sys v0.5.0 // indirect
+require (
+	github.com/stretchr/testify v1.8.1 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+)
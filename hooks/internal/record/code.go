@@ -0,0 +1,71 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package record
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefCodeMaxLines define the default maximum number of lines in code
+// block.
+const DefCodeMaxLines = 30
+
+// FieldError define the field name of error, the same as logrus.ErrorKey,
+// zap.Error, and zerolog.ErrorFieldName.
+const FieldError = "error"
+
+// IsCodeField return true if the field `key` with formatted `value` should
+// be rendered as code block, that is the FieldError or the value contains
+// multiple lines.
+func IsCodeField(key, value string) bool {
+	return key == FieldError || strings.Contains(value, "\n")
+}
+
+// FieldValue return the field value formatted with "%+v", so the error
+// that implement fmt.Formatter, like pkg/errors, is printed with its stack
+// trace.
+func FieldValue(v interface{}) string {
+	return fmt.Sprintf("%+v", v)
+}
+
+// CodeBlock wrap the `value` in Markdown fenced code block.
+//
+// If the value has more than `maxLines` lines, the rest of lines are
+// replaced with "… (n more lines)".
+// If `maxLines` is less than one, all lines are rendered.
+// The fence is longer than the longest backtick sequence in value, so the
+// value cannot close the code block.
+func CodeBlock(value string, maxLines int) string {
+	var (
+		sb    strings.Builder
+		fence = "```"
+		lines = strings.Split(strings.TrimRight(value, "\n"), "\n")
+		more  int
+	)
+
+	for n := len(fence); strings.Contains(value, fence); n++ {
+		fence = strings.Repeat("`", n+1)
+	}
+
+	if maxLines > 0 && len(lines) > maxLines {
+		more = len(lines) - maxLines
+		lines = lines[:maxLines]
+	}
+
+	sb.WriteString(fence)
+	sb.WriteByte('\n')
+	for _, line := range lines {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	if more > 0 {
+		sb.WriteString("… (" + strconv.Itoa(more) + " more lines)\n")
+	}
+	sb.WriteString(fence)
+
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package record
+
+import (
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+func TestCodeBlock(t *testing.T) {
+	tests := []struct {
+		desc     string
+		in       string
+		exp      string
+		maxLines int
+	}{{
+		desc: "With single line",
+		in:   "connection refused",
+		exp:  "```\nconnection refused\n```",
+	}, {
+		desc: "With trailing new line",
+		in:   "line 1\nline 2\n",
+		exp:  "```\nline 1\nline 2\n```",
+	}, {
+		desc:     "With max lines",
+		in:       "l1\nl2\nl3\nl4",
+		maxLines: 2,
+		exp:      "```\nl1\nl2\n… (2 more lines)\n```",
+	}, {
+		desc: "With backticks in value",
+		in:   "a ``` b\n````",
+		exp:  "`````\na ``` b\n````\n`````",
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := CodeBlock(test.in, test.maxLines)
+
+		assert(t, test.exp, got, true)
+	}
+}
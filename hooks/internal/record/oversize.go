@@ -0,0 +1,57 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package record
+
+import (
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// fitSize return list of payloads where each of them is not larger than
+// the maximum size in `opts`.
+// In attachment mode, the field values are truncated and only the
+// attachment text is split.
+func fitSize(payload webhook.Payload, opts *webhook.OversizeOptions) (
+	payloads []webhook.Payload,
+) {
+	var maxSize = opts.Size()
+
+	if maxSize == 0 {
+		return []webhook.Payload{payload}
+	}
+
+	if len(payload.Attachments) == 0 {
+		for _, part := range opts.Fit(payload.Text) {
+			sub := payload
+			sub.Text = part
+			payloads = append(payloads, sub)
+		}
+		return payloads
+	}
+
+	var attc = payload.Attachments[0]
+
+	for x, field := range attc.Fields {
+		attc.Fields[x].Value = webhook.TruncateText(field.Value, maxSize)
+	}
+
+	parts := opts.Fit(attc.Text)
+
+	attc.Text = parts[0]
+	payloads = append(payloads, payload)
+
+	for _, part := range parts[1:] {
+		sub := payload
+		sub.Attachments = []*webhook.Attachment{{
+			Timestamp: attc.Timestamp,
+			Color:     attc.Color,
+			Fallback:  attc.Fallback,
+			Title:     attc.Title,
+			TitleLink: attc.TitleLink,
+			Text:      part,
+		}}
+		payloads = append(payloads, sub)
+	}
+	return payloads
+}
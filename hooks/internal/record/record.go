@@ -0,0 +1,235 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package record contains the log record that does not depends on any
+// logging framework, and its rendering into Mattermost post.
+// It is shared by the hooks for logrus, slog, zap, and zerolog, so the log
+// from each of them has the same format.
+package record
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/internal/level"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// List of reserved fields to override the post destination per record.
+// The reserved fields are not rendered in the post.
+const (
+	FieldChannel  = "mm_channel"
+	FieldUsername = "mm_username"
+	FieldIconURL  = "mm_icon_url"
+)
+
+// FieldCaller define the field name of caller in post.
+const FieldCaller = "caller"
+
+// Options define the destination and the format of post.
+type Options struct {
+	// Attachment if not nil, the record is rendered as attachment.
+	// The value act as default attachment value, where the Color,
+	// Text, Fields, and Timestamp are set from the record.
+	Attachment *webhook.Attachment
+
+	// Channel define the channel name where the post will be send.
+	Channel string
+
+	// Username define the user name that send the post.
+	Username string
+
+	// IconURL define the profile picture of the post sender.
+	IconURL string
+
+	// CodeMaxLines define the maximum number of lines of the error and
+	// multi-line field value, that rendered as code block.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+
+	// Oversize define the options to truncate or split the post that
+	// is larger than the maximum post size in Mattermost.
+	Oversize webhook.OversizeOptions
+
+	// CallerFooter if true, the caller in attachment mode is rendered
+	// in the footer instead of field.
+	CallerFooter bool
+}
+
+// Record define the log entry from any logging framework.
+type Record struct {
+	// Time define the time when the log is created.
+	Time time.Time
+
+	// Fields contains the fields of log, including the reserved
+	// fields.
+	Fields map[string]interface{}
+
+	// Message define the log message.
+	Message string
+
+	// Caller define the source code position of log, as "file:line".
+	Caller string
+
+	// Level define the level of log.
+	Level delivery.Level
+}
+
+// Posts convert the record into one or more delivery.Post, using the
+// destination and format from `opts`.
+//
+// In text mode, the record is rendered as single line text in logfmt
+// style, followed by the error and multi-line field values in code block,
+//
+//	:icon: <field-key=field-value ...> [caller=file:line] msg=Message
+//
+// The post that is larger than the maximum size is truncated or split
+// based on the Oversize options.
+func (rec *Record) Posts(opts *Options) (posts []*delivery.Post) {
+	var payload = webhook.Payload{
+		Channel:  opts.Channel,
+		Username: opts.Username,
+		IconURL:  opts.IconURL,
+	}
+
+	fields := make(map[string]interface{}, len(rec.Fields))
+	for k, v := range rec.Fields {
+		switch k {
+		case FieldChannel:
+			payload.Channel = fmt.Sprintf("%v", v)
+		case FieldUsername:
+			payload.Username = fmt.Sprintf("%v", v)
+		case FieldIconURL:
+			payload.IconURL = fmt.Sprintf("%v", v)
+		default:
+			fields[k] = v
+		}
+	}
+
+	codeMaxLines := opts.CodeMaxLines
+	if codeMaxLines == 0 {
+		codeMaxLines = DefCodeMaxLines
+	}
+
+	if opts.Attachment != nil {
+		payload.Attachments = []*webhook.Attachment{
+			rec.attachment(opts, fields, codeMaxLines),
+		}
+	} else {
+		payload.Text = rec.text(fields, codeMaxLines)
+	}
+
+	for _, part := range fitSize(payload, &opts.Oversize) {
+		posts = append(posts, &delivery.Post{
+			Payload: part,
+			Channel: part.Channel,
+			Level:   rec.Level,
+		})
+	}
+	return posts
+}
+
+// Push convert the record into posts and push them into `sender`.
+func (rec *Record) Push(sender *delivery.Sender, opts *Options) (err error) {
+	for _, post := range rec.Posts(opts) {
+		err = sender.Push(post)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// text render the record with `fields` as text.
+func (rec *Record) text(fields map[string]interface{}, codeMaxLines int) string {
+	var (
+		sb       strings.Builder
+		codeKeys []string
+	)
+
+	sb.WriteString(level.Icons[rec.Level])
+	for _, k := range sortedKeys(fields) {
+		value := FieldValue(fields[k])
+		if IsCodeField(k, value) {
+			codeKeys = append(codeKeys, k)
+			continue
+		}
+		sb.WriteString(" " + k + "=" + value)
+	}
+	if len(rec.Caller) > 0 {
+		sb.WriteString(" " + FieldCaller + "=" + rec.Caller)
+	}
+	if len(rec.Message) > 0 {
+		sb.WriteString(" msg=" + rec.Message)
+	}
+	for _, k := range codeKeys {
+		sb.WriteString("\n" + k + ":\n")
+		sb.WriteString(CodeBlock(FieldValue(fields[k]), codeMaxLines))
+	}
+
+	return sb.String()
+}
+
+// attachment render the record with `fields` as attachment.
+// The error and multi-line field values are rendered as long field, after
+// the short fields and caller.
+func (rec *Record) attachment(opts *Options, fields map[string]interface{},
+	codeMaxLines int,
+) (attc *webhook.Attachment) {
+	var long webhook.Fields
+
+	copied := *opts.Attachment
+	attc = &copied
+	attc.Color = level.Colors[rec.Level]
+	attc.Text = level.Icons[rec.Level] + " " + rec.Message
+	attc.Timestamp = rec.Time
+	attc.Fields = make(webhook.Fields, 0, len(fields)+1)
+
+	for _, k := range sortedKeys(fields) {
+		value := FieldValue(fields[k])
+		if IsCodeField(k, value) {
+			long = append(long, webhook.Field{
+				Title: k,
+				Value: CodeBlock(value, codeMaxLines),
+			})
+			continue
+		}
+		attc.Fields = append(attc.Fields, webhook.Field{
+			Short: true,
+			Title: k,
+			Value: value,
+		})
+	}
+
+	if len(rec.Caller) > 0 {
+		if !opts.CallerFooter {
+			attc.Fields = append(attc.Fields, webhook.Field{
+				Short: true,
+				Title: FieldCaller,
+				Value: rec.Caller,
+			})
+		} else if len(attc.Footer) > 0 {
+			attc.Footer += " | " + rec.Caller
+		} else {
+			attc.Footer = rec.Caller
+		}
+	}
+
+	attc.Fields = append(attc.Fields, long...)
+
+	return attc
+}
+
+// sortedKeys return the keys in `data` sorted in ascending order.
+func sortedKeys(data map[string]interface{}) (keys []string) {
+	keys = make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package record
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+func TestRecordPosts(t *testing.T) {
+	var (
+		ts  = time.Date(2023, 2, 18, 10, 0, 0, 0, time.UTC)
+		rec = &Record{
+			Time: ts,
+			Fields: map[string]interface{}{
+				FieldError:   errors.New("card declined"),
+				"shard":      2,
+				FieldChannel: "ops",
+			},
+			Message: "payment failed",
+			Caller:  "main.go:12",
+			Level:   delivery.LevelError,
+		}
+	)
+
+	tests := []struct {
+		desc string
+		opts Options
+		exp  string
+	}{{
+		desc: "With text",
+		opts: Options{Channel: "dev", Username: "bot"},
+		exp:  `{"channel":"ops","username":"bot","text":":exclamation: shard=2 caller=main.go:12 msg=payment failed\nerror:\n` + "```" + `\ncard declined\n` + "```" + `"}`,
+	}, {
+		desc: "With attachment",
+		opts: Options{
+			Username:   "bot",
+			Attachment: &webhook.Attachment{Title: "billing"},
+		},
+		exp: `{"channel":"ops","username":"bot","attachments":[{"color":"#990000","fields":[{"short":true,"title":"shard","value":"2"},{"short":true,"title":"caller","value":"main.go:12"},{"short":false,"title":"error","value":"` + "```" + `\ncard declined\n` + "```" + `"}],"text":":exclamation: payment failed","title":"billing","ts":1676714400}]}`,
+	}, {
+		desc: "With caller in footer",
+		opts: Options{
+			Username:     "bot",
+			Attachment:   &webhook.Attachment{Footer: "billing"},
+			CallerFooter: true,
+		},
+		exp: `{"channel":"ops","username":"bot","attachments":[{"color":"#990000","fields":[{"short":true,"title":"shard","value":"2"},{"short":false,"title":"error","value":"` + "```" + `\ncard declined\n` + "```" + `"}],"footer":"billing | main.go:12","text":":exclamation: payment failed","ts":1676714400}]}`,
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		posts := rec.Posts(&test.opts)
+		assert(t, 1, len(posts), true)
+
+		post := posts[0]
+		assert(t, "ops", post.Channel, true)
+		assert(t, delivery.LevelError, post.Level, true)
+
+		got, err := post.Payload.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert(t, test.exp, string(got), true)
+	}
+
+	// The reserved fields in record is not modified.
+	assert(t, "ops", rec.Fields[FieldChannel], true)
+}
+
+func TestRecordPostsOversize(t *testing.T) {
+	var (
+		line = strings.Repeat("x", 28)
+		rec  = &Record{
+			Message: line + "\n" + line,
+			Level:   delivery.LevelInfo,
+		}
+	)
+
+	tests := []struct {
+		desc string
+		opts Options
+		exp  []string
+	}{{
+		desc: "With text truncated",
+		opts: Options{
+			Oversize: webhook.OversizeOptions{MaxSize: 64},
+		},
+		exp: []string{
+			`{"text":":white_circle: msg=` + line + `\n` + line[:15] + `…"}`,
+		},
+	}, {
+		desc: "With text split",
+		opts: Options{
+			Oversize: webhook.OversizeOptions{
+				MaxSize: 64,
+				Policy:  webhook.OversizeSplit,
+			},
+		},
+		exp: []string{
+			`{"text":"(1/2) :white_circle: msg=` + line + `"}`,
+			`{"text":"(2/2) ` + line + `"}`,
+		},
+	}, {
+		desc: "With attachment split",
+		opts: Options{
+			Attachment: &webhook.Attachment{Title: "title"},
+			Oversize: webhook.OversizeOptions{
+				MaxSize: 64,
+				Policy:  webhook.OversizeSplit,
+			},
+		},
+		exp: []string{
+			`{"attachments":[{"color":"#FFFFFF","text":"(1/2) :white_circle: ` + line + `","title":"title"}]}`,
+			`{"attachments":[{"color":"#FFFFFF","text":"(2/2) ` + line + `","title":"title"}]}`,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		var got []string
+		for _, post := range rec.Posts(&test.opts) {
+			out, err := post.Payload.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(out))
+		}
+
+		assert(t, test.exp, got, true)
+	}
+}
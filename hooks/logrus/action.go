@@ -0,0 +1,33 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// List of action type.
+const (
+	ActionTypeButton = webhook.ActionTypeButton
+	ActionTypeSelect = webhook.ActionTypeSelect
+)
+
+// List of data source for action with type select.
+const (
+	ActionDataSourceChannels = webhook.ActionDataSourceChannels
+	ActionDataSourceUsers    = webhook.ActionDataSourceUsers
+)
+
+// Action define interactive button or select menu in message attachment,
+// see webhook.Action.
+type Action = webhook.Action
+
+// ActionOption define single option in select menu, see
+// webhook.ActionOption.
+type ActionOption = webhook.ActionOption
+
+// Integration define the request that Mattermost send when the Action is
+// triggered, see webhook.Integration.
+type Integration = webhook.Integration
@@ -5,48 +5,40 @@
 package logrus
 
 import (
-	"bytes"
-	"fmt"
 	"sort"
 
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/internal/level"
+	"github.com/shuLhan/mattermost-integration/webhook"
 	"github.com/sirupsen/logrus"
 )
 
-var (
-	_colorsLevel = []string{
-		"#FF0000", // Panic
-		"#CC0000", // Fatal
-		"#990000", // Error
-		"#9F6000", // Warning
-		"#FFFFFF", // Info
-		"#000000", // Debug
-		"#000000", // Trace
-	}
-)
+// _colorsLevel contains list of attachment color based on log level.
+var _colorsLevel = level.Colors
 
 // Attachment define Mattermost message attachment [1].
 //
+// It has the same fields as webhook.Attachment, with additional methods to
+// set the fields from logrus entry.
+// The Timestamp is set from logrus.Entry.Time by NewAttachment.
+//
 // [1] https://docs.mattermost.com/developer/message-attachments.html
-type Attachment struct {
-	AuthorIcon string
-	AuthorLink string
-	AuthorName string
-	Color      string
-	Fallback   string
-	ImageURL   string
-	Pretext    string
-	Text       string
-	Title      string
-	TitleLink  string
-
-	Fields     Fields
-}
+type Attachment webhook.Attachment
 
 // NewAttachment will create and return new Attachment with default value set
 // from `attc` and Color and Fields based on logrus Entry Level and Data.
+// The Timestamp is set to the logrus Entry Time, if its not zero.
 func NewAttachment(defAttc *Attachment, entry *logrus.Entry) (
 	attc *Attachment,
 ) {
+	return newAttachment(defAttc, entry, record.DefCodeMaxLines)
+}
+
+// newAttachment create new Attachment with the multi-line field value
+// rendered as code block with at most `codeMaxLines` lines.
+func newAttachment(defAttc *Attachment, entry *logrus.Entry,
+	codeMaxLines int,
+) (attc *Attachment) {
 	if defAttc == nil {
 		return
 	}
@@ -55,105 +47,40 @@ func NewAttachment(defAttc *Attachment, entry *logrus.Entry) (
 		AuthorIcon: defAttc.AuthorIcon,
 		AuthorLink: defAttc.AuthorLink,
 		AuthorName: defAttc.AuthorName,
+		Footer:     defAttc.Footer,
+		FooterIcon: defAttc.FooterIcon,
 		ImageURL:   defAttc.ImageURL,
 		Pretext:    defAttc.Pretext,
+		ThumbURL:   defAttc.ThumbURL,
 		Title:      defAttc.Title,
 		TitleLink:  defAttc.TitleLink,
+		Actions:    defAttc.Actions,
 	}
 
 	if entry != nil {
 		attc.Color = _colorsLevel[entry.Level]
-		attc.SetFields(entry.Data)
+		attc.setFields(entry.Data, codeMaxLines)
 		attc.Text = _iconsLevel[entry.Level] + " " + entry.Message
+		attc.Timestamp = entry.Time
 	}
 
 	return
 }
 
-func (attc Attachment) marshalAuthor(buf *bytes.Buffer) {
-	if len(attc.AuthorIcon) > 0 {
-		_ = bufWriteKV(buf, `"author_icon"`, []byte(attc.AuthorIcon),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.AuthorLink) > 0 {
-		_ = bufWriteKV(buf, `"author_link"`, []byte(attc.AuthorLink),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.AuthorName) > 0 {
-		_ = bufWriteKV(buf, `"author_name"`, []byte(attc.AuthorName),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-}
-
 // MarshalJSON will convert Attachment `attc` to JSON.
 func (attc Attachment) MarshalJSON() (out []byte, err error) {
-	var buf bytes.Buffer
-	var bFields []byte
-
-	_ = buf.WriteByte('{')
-
-	attc.marshalAuthor(&buf)
-
-	if len(attc.Color) > 0 {
-		_ = bufWriteKV(&buf, `"color"`, []byte(attc.Color),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.Fallback) > 0 {
-		_ = bufWriteKV(&buf, `"fallback"`, []byte(attc.Fallback),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.Fields) > 0 {
-		bFields, err = attc.Fields.MarshalJSON()
-		if err != nil {
-			return
-		}
-
-		_, _ = buf.WriteString(`"fields":`)
-		_, _ = buf.Write(bFields)
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.ImageURL) > 0 {
-		_ = bufWriteKV(&buf, `"image_url"`, []byte(attc.ImageURL),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.Pretext) > 0 {
-		_ = bufWriteKV(&buf, `"pretext"`, []byte(attc.Pretext),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-
-	}
-	if len(attc.Text) > 0 {
-		_ = bufWriteKV(&buf, `"text"`, []byte(attc.Text),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.Title) > 0 {
-		_ = bufWriteKV(&buf, `"title"`, []byte(attc.Title),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-	if len(attc.TitleLink) > 0 {
-		_ = bufWriteKV(&buf, `"title_link"`, []byte(attc.TitleLink),
-			':', '"', '"')
-		_ = buf.WriteByte(',')
-	}
-
-	out = buf.Bytes()
-
-	out = bytes.TrimSuffix(out, []byte(","))
-	out = append(out, []byte("}")...)
-
-	return
+	return webhook.Attachment(attc).MarshalJSON()
 }
 
 // SetFields will convert logrus Fields data `in` into our Fields.
+//
+// The logrus.ErrorKey and field with multi-line value are rendered as long
+// field, after the short fields, with its value wrapped in code block.
 func (attc *Attachment) SetFields(in logrus.Fields) {
+	attc.setFields(in, record.DefCodeMaxLines)
+}
+
+func (attc *Attachment) setFields(in logrus.Fields, codeMaxLines int) {
 	attc.Fields = make(Fields, 0)
 
 	if len(in) == 0 {
@@ -166,11 +93,22 @@ func (attc *Attachment) SetFields(in logrus.Fields) {
 	}
 	sort.Strings(keys)
 
+	var long Fields
+
 	for _, k := range keys {
+		value := record.FieldValue(in[k])
+		if record.IsCodeField(k, value) {
+			long = append(long, Field{
+				Title: k,
+				Value: record.CodeBlock(value, codeMaxLines),
+			})
+			continue
+		}
 		attc.Fields = append(attc.Fields, Field{
 			Short: true,
 			Title: k,
-			Value: fmt.Sprintf("%+v", in[k]),
+			Value: value,
 		})
 	}
+	attc.Fields = append(attc.Fields, long...)
 }
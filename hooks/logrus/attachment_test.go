@@ -5,61 +5,91 @@
 package logrus
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/sirupsen/logrus"
 )
 
-func TestAttachmentMarshalJSON(t *testing.T) {
-	tests := []struct {
-		desc string
-		exp  string
-		in   Attachment
-	}{
-		{
-			desc: "With empty fields",
-			in:   Attachment{},
-			exp:  `{}`,
-		},
-		{
-			desc: "With empty author_icon",
-			in: Attachment{
-				AuthorIcon: "",
-				AuthorLink: "authorlink",
-			},
-			exp: `{"author_link":"authorlink"}`,
-		},
-		{
-			desc: "With fields",
-			in: Attachment{
-				AuthorIcon: "",
-				AuthorLink: "authorlink",
-				Fields: Fields{
-					{
-						Short: false,
-						Title: "t1",
-						Value: "v1",
-					},
-					{
-						Short: true,
-					},
-					{
-						Short: true,
-						Title: "t3",
-						Value: "v3",
-					},
-				},
-			},
-			exp: `{"author_link":"authorlink","fields":[{"short":false,"title":"t1","value":"v1"},{"short":true,"title":"t3","value":"v3"}]}`,
-		},
+func TestNewAttachment(t *testing.T) {
+	var (
+		defAttc = &Attachment{
+			Footer:     "my-app",
+			FooterIcon: "https://example.com/footer.png",
+			ThumbURL:   "https://example.com/thumb.png",
+			Actions: []Action{{
+				ID:   "ack",
+				Name: "Acknowledge",
+			}},
+		}
+		entry = &logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Message: "disk full",
+			Time:    time.Unix(1676714400, 0),
+		}
+		exp = `{"actions":[{"id":"ack","name":"Acknowledge"}],"color":"#990000","footer":"my-app","footer_icon":"https://example.com/footer.png","text":":exclamation: disk full","thumb_url":"https://example.com/thumb.png","ts":1676714400}`
+	)
+
+	attc := NewAttachment(defAttc, entry)
+
+	got, err := attc.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for _, test := range tests {
-		t.Log(test.desc)
+	assert(t, exp, string(got), true)
+}
+
+func FuzzAttachmentMarshalJSON(f *testing.F) {
+	f.Add("title", "key", "value", "message")
+	f.Add("", "err", "line 1\n\tline 2", "panic: \"nil\"\r\n")
+	f.Add("t\x00", "k\xff", "v\\", "\x1b[31mred\x1b[0m")
+
+	f.Fuzz(func(t *testing.T, title, key, value, text string) {
+		var (
+			entry = &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Data:    logrus.Fields{key: value},
+				Message: text,
+			}
+			attc = NewAttachment(&Attachment{Title: title}, entry)
+			got  struct {
+				Title  string
+				Text   string
+				Fields []struct {
+					Title string
+					Value string
+					Short bool
+				}
+			}
+		)
 
-		got, err := test.in.MarshalJSON()
+		out, err := attc.MarshalJSON()
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		assert(t, test.exp, string(got), true)
-	}
+		err = json.Unmarshal(out, &got)
+		if err != nil {
+			t.Fatalf("%q: %s", out, err)
+		}
+
+		assert(t, string([]rune(title)), got.Title, true)
+		assert(t, string([]rune(attc.Text)), got.Text, true)
+
+		if len(key) == 0 || len(value) == 0 {
+			assert(t, 0, len(got.Fields), true)
+			return
+		}
+		assert(t, 1, len(got.Fields), true)
+		isCode := record.IsCodeField(key, value)
+		if isCode {
+			value = record.CodeBlock(value, record.DefCodeMaxLines)
+		}
+		assert(t, string([]rune(key)), got.Fields[0].Title, true)
+		assert(t, string([]rune(value)), got.Fields[0].Value, true)
+		assert(t, !isCode, got.Fields[0].Short, true)
+	})
 }
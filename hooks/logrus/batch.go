@@ -0,0 +1,139 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defBatchSize   = 20
+	defBatchWindow = 5 * time.Second
+)
+
+// BatchOptions define the options to send multiple log entries as single
+// Mattermost post.
+//
+// The batch is send when the number of entries reach the Size or after
+// the Window since the first entry in the batch, whichever come first.
+// The log with level panic or fatal will send the batch immediately.
+//
+// In text mode, each entry is rendered on its own line.
+// In attachment mode, each entry is rendered as one attachment.
+type BatchOptions struct {
+	// Size define the maximum number of entries in one batch.
+	// Batching is enabled if Size is greater than one or Window is
+	// greater than zero.
+	// If Window is set, default to 20.
+	Size int
+
+	// Window define the maximum duration to wait for the batch to be
+	// full.
+	// If Size is set, default to 5 seconds.
+	Window time.Duration
+}
+
+func (opts *BatchOptions) init() {
+	if !opts.enabled() {
+		return
+	}
+	if opts.Size <= 0 {
+		opts.Size = defBatchSize
+	}
+	if opts.Window <= 0 {
+		opts.Window = defBatchWindow
+	}
+}
+
+func (opts *BatchOptions) enabled() bool {
+	return opts.Size > 1 || opts.Window > 0
+}
+
+// batchQueue contains the messages for the same destination that will be
+// send as single message.
+type batchQueue struct {
+	timer *time.Timer
+	msgs  []*Message
+}
+
+// batchAdd add the message into batch for its destination.
+// The batch will be pushed into queue if its full or the message level is
+// panic or fatal.
+//
+// It will return ErrClosed if the hook has been closed.
+func (hook *Hook) batchAdd(msg *Message) (err error) {
+	var dest = msg.destination()
+
+	hook.batchLocker.Lock()
+	defer hook.batchLocker.Unlock()
+
+	if hook.closed {
+		return ErrClosed
+	}
+
+	bq := hook.batches[dest]
+	if bq == nil {
+		bq = &batchQueue{}
+		hook.batches[dest] = bq
+	}
+
+	bq.msgs = append(bq.msgs, msg)
+
+	if len(bq.msgs) >= hook.opts.Batch.Size ||
+		msg.entryLevel <= logrus.FatalLevel {
+		return hook.batchEnqueue(dest)
+	}
+	if len(bq.msgs) == 1 {
+		bq.timer = time.AfterFunc(hook.opts.Batch.Window, func() {
+			hook.batchExpire(dest, bq)
+		})
+	}
+
+	return nil
+}
+
+// batchExpire push the batch `bq` for destination `dest` into queue when
+// its window has passed.
+// The timer may fire after the batch has been pushed by size or flush,
+// so it is pushed only if it is still the current batch.
+func (hook *Hook) batchExpire(dest string, bq *batchQueue) {
+	hook.batchLocker.Lock()
+	if hook.batches[dest] == bq {
+		_ = hook.batchEnqueue(dest)
+	}
+	hook.batchLocker.Unlock()
+}
+
+// batchFlush push all of the current batches, if any, into queue.
+func (hook *Hook) batchFlush() {
+	hook.batchLocker.Lock()
+	for dest := range hook.batches {
+		_ = hook.batchEnqueue(dest)
+	}
+	hook.batchLocker.Unlock()
+}
+
+// batchEnqueue push the current batch for destination `dest` into queue
+// as single message.
+// The caller must hold the batchLocker.
+func (hook *Hook) batchEnqueue(dest string) (err error) {
+	bq := hook.batches[dest]
+	if bq == nil {
+		return nil
+	}
+
+	delete(hook.batches, dest)
+
+	if bq.timer != nil {
+		bq.timer.Stop()
+	}
+	if len(bq.msgs) == 0 {
+		return nil
+	}
+
+	return hook.enqueue(newBatchMessage(bq.msgs))
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHookBatch(t *testing.T) {
+	tests := []struct {
+		desc string
+		attc *Attachment
+		in   []logrus.Entry
+		exp  []string
+		opts BatchOptions
+	}{
+		{
+			desc: "With batch size",
+			opts: BatchOptions{
+				Size:   2,
+				Window: time.Hour,
+			},
+			in: []logrus.Entry{
+				{Level: logrus.InfoLevel, Message: "m1"},
+				{Level: logrus.WarnLevel, Message: "m2"},
+				{Level: logrus.InfoLevel, Message: "m3"},
+				{Level: logrus.InfoLevel, Message: "m4"},
+			},
+			exp: []string{
+				`{"username":"` + _hostname + `","text":":white_circle: msg=m1\n:interrobang: msg=m2"}`,
+				`{"username":"` + _hostname + `","text":":white_circle: msg=m3\n:white_circle: msg=m4"}`,
+			},
+		},
+		{
+			desc: "With batch window",
+			opts: BatchOptions{
+				Size:   10,
+				Window: 20 * time.Millisecond,
+			},
+			in: []logrus.Entry{
+				{Level: logrus.InfoLevel, Message: "m1"},
+				{Level: logrus.InfoLevel, Message: "m2"},
+			},
+			exp: []string{
+				`{"username":"` + _hostname + `","text":":white_circle: msg=m1\n:white_circle: msg=m2"}`,
+			},
+		},
+		{
+			desc: "With fatal level",
+			opts: BatchOptions{
+				Size:   10,
+				Window: time.Hour,
+			},
+			in: []logrus.Entry{
+				{Level: logrus.InfoLevel, Message: "m1"},
+				{Level: logrus.FatalLevel, Message: "m2"},
+			},
+			exp: []string{
+				`{"username":"` + _hostname + `","text":":white_circle: msg=m1\n:bangbang: msg=m2"}`,
+			},
+		},
+		{
+			desc: "With attachment",
+			opts: BatchOptions{
+				Size: 2,
+			},
+			attc: &Attachment{},
+			in: []logrus.Entry{
+				{Level: logrus.InfoLevel, Message: "m1"},
+				{Level: logrus.ErrorLevel, Message: "m2"},
+			},
+			exp: []string{
+				`{"username":"` + _hostname + `","attachments":[{"color":"#FFFFFF","text":":white_circle: m1"},{"color":"#990000","text":":exclamation: m2"}]}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		hook, chanBody := newTestHook(t, Options{
+			Attachment: test.attc,
+			Batch:      test.opts,
+			MinLevel:   logrus.TraceLevel,
+		})
+
+		for x := range test.in {
+			err := hook.Fire(&test.in[x])
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The messages may be send concurrently.
+		got := make([]string, 0, len(test.exp))
+		for range test.exp {
+			got = append(got, <-chanBody)
+		}
+		sort.Strings(got)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestHookBatchFlush(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		Batch: BatchOptions{
+			Size:   10,
+			Window: time.Hour,
+		},
+		MinLevel: logrus.InfoLevel,
+	})
+
+	_ = hook.Fire(&logrus.Entry{Level: logrus.InfoLevel, Message: "m1"})
+
+	n, err := hook.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, 0, n, true)
+	assert(t, `{"username":"`+_hostname+`","text":":white_circle: msg=m1"}`, <-chanBody, true)
+}
+
+func TestHookBatchExpireStale(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		Batch: BatchOptions{
+			Size:   2,
+			Window: time.Hour,
+		},
+		MinLevel: logrus.InfoLevel,
+	})
+
+	var (
+		dest  string
+		stale *batchQueue
+	)
+
+	_ = hook.Fire(&logrus.Entry{Level: logrus.InfoLevel, Message: "m1"})
+
+	hook.batchLocker.Lock()
+	for k, bq := range hook.batches {
+		dest, stale = k, bq
+	}
+	hook.batchLocker.Unlock()
+
+	// The first batch is pushed by size and the new batch is started.
+	_ = hook.Fire(&logrus.Entry{Level: logrus.InfoLevel, Message: "m2"})
+	_ = hook.Fire(&logrus.Entry{Level: logrus.InfoLevel, Message: "m3"})
+
+	assert(t, `{"username":"`+_hostname+`","text":":white_circle: msg=m1\n:white_circle: msg=m2"}`, <-chanBody, true)
+
+	// The timer of the first batch should not push the new batch.
+	hook.batchExpire(dest, stale)
+
+	hook.batchLocker.Lock()
+	current := hook.batches[dest]
+	hook.batchLocker.Unlock()
+
+	if current == nil || len(current.msgs) != 1 {
+		t.Fatalf("expecting the new batch is not pushed, got %+v", current)
+	}
+}
+
+func TestHookBatchClosed(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		MinLevel: logrus.InfoLevel,
+		Batch: BatchOptions{
+			Size:   10,
+			Window: time.Hour,
+		},
+	})
+
+	_, err := hook.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = hook.Fire(&logrus.Entry{Level: logrus.InfoLevel, Message: "m1"})
+	assert(t, ErrClosed, err, true)
+
+	hook.batchLocker.Lock()
+	assert(t, 0, len(hook.batches), true)
+	hook.batchLocker.Unlock()
+	assert(t, 0, len(chanBody), true)
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/sirupsen/logrus"
+)
+
+// FieldCaller define the field name of caller information in message and
+// attachment.
+const FieldCaller = record.FieldCaller
+
+// defRevision define the revision in caller URL if the Revision is not
+// set and the program is build without VCS information.
+const defRevision = "HEAD"
+
+// Revision define the revision of program that is used in caller URL.
+// It can be set when building the program, for example
+//
+//	go build -ldflags "-X github.com/shuLhan/mattermost-integration/hooks/logrus.Revision=$(git rev-parse HEAD)"
+//
+// If its empty, the "vcs.revision" from build information is used.
+var Revision string
+
+var (
+	_vcsRevision     string
+	_vcsRevisionOnce sync.Once
+)
+
+// CallerOptions define the options to render the caller information,
+// when the logger set with ReportCaller.
+//
+// In text mode, the caller is rendered as field "caller=file:line".
+// In attachment mode, the caller is rendered as short field "caller" or in
+// the footer.
+type CallerOptions struct {
+	// URL define the template to convert the caller into link to
+	// source browser, for example
+	//
+	//	https://git.example.com/{repo}/blob/{rev}/{file}#L{line}
+	//
+	// The "{repo}" is replaced with Repo, "{rev}" with Revision,
+	// "{file}" with the caller file without TrimPrefix, "{line}" with
+	// the caller line, and "{func}" with the caller function.
+	// If its empty, the caller is rendered without link.
+	URL string
+
+	// Repo define the repository name in URL.
+	Repo string
+
+	// Revision define the revision in URL.
+	// Default to the package variable Revision, or the VCS revision in
+	// build information, or "HEAD".
+	Revision string
+
+	// TrimPrefix define the prefix that is removed from the caller
+	// file, for example the path of repository in build machine.
+	TrimPrefix string
+
+	// Footer if true, the caller in attachment mode is rendered in
+	// the footer instead of field.
+	// Since the footer does not support Markdown, the caller is
+	// rendered without link.
+	Footer bool
+}
+
+// init set the default value for CallerOptions.
+func (opts *CallerOptions) init() {
+	if len(opts.URL) == 0 || len(opts.Revision) > 0 {
+		return
+	}
+	opts.Revision = buildRevision()
+}
+
+// buildRevision return the package Revision, or the VCS revision from
+// build information, or defRevision.
+func buildRevision() string {
+	if len(Revision) > 0 {
+		return Revision
+	}
+
+	_vcsRevisionOnce.Do(func() {
+		_vcsRevision = defRevision
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) > 0 {
+				_vcsRevision = setting.Value
+				return
+			}
+		}
+	})
+
+	return _vcsRevision
+}
+
+// format return the caller of log entry as "file:line" and as Markdown
+// link, if URL is set.
+// It return empty strings if the entry does not have caller.
+func (opts *CallerOptions) format(entry *logrus.Entry) (text, link string) {
+	if entry == nil || !entry.HasCaller() {
+		return "", ""
+	}
+
+	var (
+		file = entry.Caller.File
+		line = strconv.Itoa(entry.Caller.Line)
+	)
+
+	if len(opts.TrimPrefix) > 0 {
+		file = strings.TrimPrefix(file, opts.TrimPrefix)
+		file = strings.TrimPrefix(file, "/")
+	}
+	text = file + ":" + line
+
+	if len(opts.URL) == 0 {
+		return text, text
+	}
+
+	rev := opts.Revision
+	if len(rev) == 0 {
+		rev = buildRevision()
+	}
+
+	url := strings.NewReplacer(
+		"{repo}", opts.Repo,
+		"{rev}", rev,
+		"{file}", file,
+		"{line}", line,
+		"{func}", entry.Caller.Function,
+	).Replace(opts.URL)
+
+	return text, "[" + text + "](" + url + ")"
+}
+
+// setCaller set the caller information of log entry in message, using the
+// CallerOptions `opts`.
+func (msg *Message) setCaller(entry *logrus.Entry, opts *CallerOptions) {
+	text, link := opts.format(entry)
+	if len(text) == 0 {
+		return
+	}
+
+	if msg.attc == nil {
+		msg.caller = link
+		return
+	}
+	if opts.Footer {
+		if len(msg.attc.Footer) > 0 {
+			msg.attc.Footer += " | " + text
+		} else {
+			msg.attc.Footer = text
+		}
+		return
+	}
+
+	// Insert the caller after the short fields, before the code block
+	// fields.
+	var x int
+	for x < len(msg.attc.Fields) && msg.attc.Fields[x].Short {
+		x++
+	}
+	fields := make(Fields, 0, len(msg.attc.Fields)+1)
+	fields = append(fields, msg.attc.Fields[:x]...)
+	fields = append(fields, Field{
+		Short: true,
+		Title: FieldCaller,
+		Value: link,
+	})
+	msg.attc.Fields = append(fields, msg.attc.Fields[x:]...)
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/sirupsen/logrus"
+)
+
+func newCallerEntry() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetReportCaller(true)
+
+	entry := logrus.NewEntry(logger)
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "failed"
+	entry.Caller = &runtime.Frame{
+		File:     "/build/src/app/cmd/app/main.go",
+		Line:     42,
+		Function: "main.main",
+	}
+	return entry
+}
+
+func TestCallerOptionsFormat(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    CallerOptions
+		expText string
+		expLink string
+		noCall  bool
+	}{{
+		desc:    "Without URL",
+		expText: "/build/src/app/cmd/app/main.go:42",
+		expLink: "/build/src/app/cmd/app/main.go:42",
+	}, {
+		desc: "With URL",
+		opts: CallerOptions{
+			URL:        "https://git.example.com/{repo}/blob/{rev}/{file}#L{line}",
+			Repo:       "app",
+			Revision:   "abc123",
+			TrimPrefix: "/build/src/app",
+		},
+		expText: "cmd/app/main.go:42",
+		expLink: "[cmd/app/main.go:42](https://git.example.com/app/blob/abc123/cmd/app/main.go#L42)",
+	}, {
+		desc:   "Without ReportCaller",
+		noCall: true,
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		entry := newCallerEntry()
+		if test.noCall {
+			entry.Logger.SetReportCaller(false)
+		}
+
+		gotText, gotLink := test.opts.format(entry)
+
+		assert(t, test.expText, gotText, true)
+		assert(t, test.expLink, gotLink, true)
+	}
+}
+
+func TestCallerOptionsInit(t *testing.T) {
+	Revision = "v1.0.0"
+	defer func() {
+		Revision = ""
+	}()
+
+	opts := CallerOptions{URL: "https://example.com/{rev}/{file}"}
+	opts.init()
+	assert(t, "v1.0.0", opts.Revision, true)
+
+	opts = CallerOptions{URL: "https://example.com", Revision: "abc"}
+	opts.init()
+	assert(t, "abc", opts.Revision, true)
+}
+
+func TestMessageSetCaller(t *testing.T) {
+	var (
+		opts = CallerOptions{
+			URL:        "https://git.example.com/blob/{rev}/{file}#L{line}",
+			Revision:   "abc",
+			TrimPrefix: "/build/src/app/",
+		}
+		tests = []struct {
+			desc   string
+			attc   *Attachment
+			exp    string
+			footer bool
+		}{{
+			desc: "With text",
+			exp:  `{"username":"bot","text":":exclamation: caller=[cmd/app/main.go:42](https://git.example.com/blob/abc/cmd/app/main.go#L42) msg=failed"}`,
+		}, {
+			desc: "With attachment",
+			attc: &Attachment{},
+			exp:  `{"username":"bot","attachments":[{"color":"#990000","fields":[{"short":true,"title":"caller","value":"[cmd/app/main.go:42](https://git.example.com/blob/abc/cmd/app/main.go#L42)"}],"text":":exclamation: failed"}]}`,
+		}, {
+			desc:   "With attachment footer",
+			attc:   &Attachment{Footer: "my-app"},
+			footer: true,
+			exp:    `{"username":"bot","attachments":[{"color":"#990000","footer":"my-app | cmd/app/main.go:42","text":":exclamation: failed"}]}`,
+		}}
+	)
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		var (
+			entry  = newCallerEntry()
+			msg, _ = newMessageEntry("", "bot", "", entry)
+		)
+
+		msg.attc = newAttachment(test.attc, entry, record.DefCodeMaxLines)
+
+		opts.Footer = test.footer
+		msg.setCaller(entry, &opts)
+
+		got, err := msg.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, string(got), true)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/internal/duration"
+	"github.com/sirupsen/logrus"
+)
+
+// dedupMinTick define the minimum interval to check the expired entries.
+const dedupMinTick = time.Second
+
+// DedupOptions define the options to suppress repeated log entries.
+//
+// Each log entry is identified by fingerprint, computed from its level,
+// message, and the value of selected Fields.
+// The first entry is send as usual, while the next entries with the same
+// fingerprint within the Window are suppressed and counted.
+// At the end of Window, if there are suppressed entries, one message
+// is send with the number of repeated entries and the time when the entry
+// first and last seen.
+type DedupOptions struct {
+	// Fields define list of field keys in logrus.Entry.Data that are
+	// included in the fingerprint.
+	// The default is only level and message.
+	Fields []string
+
+	// Window define the duration to suppress the repeated entries.
+	// Deduplication is enabled if Window is greater than zero.
+	// The expired entries are checked every half of Window, at least
+	// every one second, so the report for Window less than two seconds
+	// may be send up to one second after the Window end.
+	Window time.Duration
+}
+
+// dedupEntry contains the state of repeated log entry.
+type dedupEntry struct {
+	first       time.Time
+	last        time.Time
+	expire      time.Time
+	data        logrus.Fields
+	fingerprint string
+	message     string
+	count       int
+	level       logrus.Level
+}
+
+// fingerprint return the identity of log entry, computed from level,
+// message, and the value of selected fields.
+func (opts *DedupOptions) fingerprint(entry *logrus.Entry) string {
+	var hash = fnv.New64a()
+
+	fmt.Fprintf(hash, "%d\x00%s", entry.Level, entry.Message)
+	for _, k := range opts.Fields {
+		v, ok := entry.Data[k]
+		if ok {
+			fmt.Fprintf(hash, "\x00%s=%+v", k, v)
+		}
+	}
+
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
+
+// dedup check if the log entry with fingerprint `fp` should be send.
+// It will return false if the same entry has been send within the window.
+func (hook *Hook) dedup(entry *logrus.Entry, fp string) bool {
+	var now = entry.Time
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	hook.dedupLocker.Lock()
+
+	expired := hook.dedupEntries[fp]
+	if expired != nil && now.Before(expired.expire) {
+		expired.count++
+		expired.last = now
+		hook.dedupLocker.Unlock()
+		return false
+	}
+
+	de := &dedupEntry{
+		first:       now,
+		last:        now,
+		expire:      now.Add(hook.opts.Dedup.Window),
+		level:       entry.Level,
+		fingerprint: fp,
+		message:     entry.Message,
+		data:        make(logrus.Fields, len(hook.opts.Dedup.Fields)),
+	}
+	for _, k := range hook.opts.Dedup.Fields {
+		v, ok := entry.Data[k]
+		if ok {
+			de.data[k] = v
+		}
+	}
+	hook.dedupEntries[fp] = de
+	hook.dedupLocker.Unlock()
+
+	if expired != nil {
+		hook.dedupReport(expired)
+	}
+
+	return true
+}
+
+// dedupSweep send the report for repeated entries that has been expired,
+// or all entries if `all` is true.
+func (hook *Hook) dedupSweep(now time.Time, all bool) {
+	var expired []*dedupEntry
+
+	hook.dedupLocker.Lock()
+	for fp, de := range hook.dedupEntries {
+		if all || !now.Before(de.expire) {
+			expired = append(expired, de)
+			delete(hook.dedupEntries, fp)
+		}
+	}
+	hook.dedupLocker.Unlock()
+
+	// The reports are send after releasing the lock, so Fire is not
+	// blocked while routing them.
+	for _, de := range expired {
+		hook.dedupReport(de)
+	}
+}
+
+// dedupReport send the number of repeated entry, if any.
+// The report is routed like the log entry, using the selected fields, and
+// has the same fingerprint as the log entry.
+// The entry `de` must have been removed from dedupEntries.
+func (hook *Hook) dedupReport(de *dedupEntry) {
+	if de.count == 0 {
+		return
+	}
+
+	var data = make(logrus.Fields, len(de.data)+3)
+
+	for k, v := range de.data {
+		data[k] = v
+	}
+	data["repeated"] = de.count
+	data["first_seen"] = de.first.Format(time.RFC3339)
+	data["last_seen"] = de.last.Format(time.RFC3339)
+
+	_ = hook.route(&logrus.Entry{
+		Level: de.level,
+		Time:  de.last,
+		Data:  data,
+		Message: fmt.Sprintf("%s (repeated %s times in the last %s)",
+			de.message, formatCount(de.count),
+			duration.Format(hook.opts.Dedup.Window)),
+	}, de.fingerprint)
+}
+
+// dedupSweeper periodically send the report of repeated entries, until
+// the hook is closed.
+func (hook *Hook) dedupSweeper() {
+	var tick = hook.opts.Dedup.Window / 2
+
+	if tick < dedupMinTick {
+		tick = dedupMinTick
+	}
+
+	var ticker = time.NewTicker(tick)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			hook.dedupSweep(now, false)
+		case <-hook.ctx.Done():
+			return
+		}
+	}
+}
+
+// formatCount format number `n` with comma as thousands separator, for
+// example 1532 become "1,532".
+func formatCount(n int) string {
+	var (
+		s  = strconv.Itoa(n)
+		sb strings.Builder
+	)
+
+	if n < 0 {
+		sb.WriteByte('-')
+		s = s[1:]
+	}
+	for x, c := range s {
+		if x > 0 && (len(s)-x)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
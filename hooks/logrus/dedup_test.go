@@ -0,0 +1,199 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDedupOptionsFingerprint(t *testing.T) {
+	opts := DedupOptions{
+		Fields: []string{"component"},
+	}
+
+	base := &logrus.Entry{
+		Level:   logrus.ErrorLevel,
+		Message: "connection refused",
+		Data: logrus.Fields{
+			"component": "db",
+			"attempt":   1,
+		},
+	}
+
+	tests := []struct {
+		in   *logrus.Entry
+		desc string
+		exp  bool
+	}{
+		{
+			desc: "With different unselected field",
+			in: &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Message: "connection refused",
+				Data: logrus.Fields{
+					"component": "db",
+					"attempt":   2,
+				},
+			},
+			exp: true,
+		},
+		{
+			desc: "With different selected field",
+			in: &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Message: "connection refused",
+				Data: logrus.Fields{
+					"component": "cache",
+				},
+			},
+		},
+		{
+			desc: "With different level",
+			in: &logrus.Entry{
+				Level:   logrus.WarnLevel,
+				Message: "connection refused",
+				Data: logrus.Fields{
+					"component": "db",
+				},
+			},
+		},
+		{
+			desc: "With different message",
+			in: &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Message: "connection reset",
+				Data: logrus.Fields{
+					"component": "db",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := opts.fingerprint(base) == opts.fingerprint(test.in)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestHookDedup(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		Dedup: DedupOptions{
+			Fields: []string{"component"},
+			Window: time.Hour,
+		},
+		MinLevel: logrus.ErrorLevel,
+	})
+
+	start := time.Date(2023, 2, 18, 10, 0, 0, 0, time.UTC)
+
+	for x := 0; x < 5; x++ {
+		err := hook.Fire(&logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Time:    start.Add(time.Duration(x) * time.Second),
+			Message: "connection refused",
+			Data: logrus.Fields{
+				"component": "db",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The next entry after window should send the report and the
+	// entry itself.
+	err := hook.Fire(&logrus.Entry{
+		Level:   logrus.ErrorLevel,
+		Time:    start.Add(2 * time.Hour),
+		Message: "connection refused",
+		Data: logrus.Fields{
+			"component": "db",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		`{"username":"` + _hostname + `","text":":exclamation: component=db first_seen=2023-02-18T10:00:00Z last_seen=2023-02-18T10:00:04Z repeated=4 msg=connection refused (repeated 4 times in the last 1h)"}`,
+		`{"username":"` + _hostname + `","text":":exclamation: component=db msg=connection refused"}`,
+		`{"username":"` + _hostname + `","text":":exclamation: component=db msg=connection refused"}`,
+	}
+
+	// The messages may be send concurrently.
+	got := make([]string, 0, len(exp))
+	for range exp {
+		got = append(got, <-chanBody)
+	}
+	sort.Strings(got)
+
+	assert(t, exp, got, true)
+
+	// The entry that has not been repeated should not be reported on
+	// Close.
+	_, err = hook.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, 0, len(chanBody), true)
+}
+
+func TestHookDedupSmallWindow(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		Dedup: DedupOptions{
+			Window: time.Nanosecond,
+		},
+		MinLevel: logrus.ErrorLevel,
+	})
+
+	now := time.Now()
+
+	for x := 0; x < 2; x++ {
+		err := hook.Fire(&logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Time:    now,
+			Message: "connection refused",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert(t, `{"username":"`+_hostname+`","text":":exclamation: msg=connection refused"}`,
+		<-chanBody, true)
+
+	// The report should be send by the sweeper.
+	got := <-chanBody
+	if !strings.Contains(got, "repeated=1 ") {
+		t.Fatalf("expecting report of repeated entry, got %s", got)
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		exp string
+		in  int
+	}{
+		{in: 0, exp: "0"},
+		{in: 999, exp: "999"},
+		{in: 1532, exp: "1,532"},
+		{in: 1234567, exp: "1,234,567"},
+		{in: -1532, exp: "-1,532"},
+	}
+
+	for _, test := range tests {
+		assert(t, test.exp, formatCount(test.in), true)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/sirupsen/logrus"
+)
+
+// List of types from package delivery that are used in Options and
+// OnError.
+type (
+	// DeliveryError define an error when the message failed to be send
+	// to Mattermost, see delivery.Error.
+	DeliveryError = delivery.Error
+
+	// Histogram define the distribution of latency, see
+	// delivery.Histogram.
+	Histogram = delivery.Histogram
+
+	// Metrics contains the delivery metrics for single level and
+	// destination, see delivery.Metrics.
+	Metrics = delivery.Metrics
+
+	// OverflowPolicy define what Fire will do when the message queue is
+	// full, see delivery.OverflowPolicy.
+	OverflowPolicy = delivery.OverflowPolicy
+
+	// RetryPolicy define how the failed request to Mattermost will be
+	// retried, see delivery.RetryPolicy.
+	RetryPolicy = delivery.RetryPolicy
+
+	// SpoolOptions define the options for durable, file-backed queue,
+	// see delivery.SpoolOptions.
+	SpoolOptions = delivery.SpoolOptions
+)
+
+// List of overflow policy, see delivery.OverflowPolicy.
+const (
+	OverflowBlock      = delivery.OverflowBlock
+	OverflowDropNewest = delivery.OverflowDropNewest
+	OverflowDropOldest = delivery.OverflowDropOldest
+	OverflowDropBelow  = delivery.OverflowDropBelow
+)
+
+// List of metrics constants, see delivery.MetricsVarName and
+// delivery.LevelUnknown.
+const (
+	MetricsVarName = delivery.MetricsVarName
+	LevelUnknown   = delivery.LevelUnknown
+)
+
+// MetricsSnapshot return the current metrics of all hooks and senders,
+// see delivery.MetricsSnapshot.
+func MetricsSnapshot() (list []Metrics) {
+	return delivery.MetricsSnapshot()
+}
+
+// Dropped return the total number of messages that has been dropped,
+// either because the queue is full, the spool is full, or the message is
+// older than the spool maximum age.
+func (hook *Hook) Dropped() (n int64) {
+	return hook.sender.Dropped()
+}
+
+// deliveryOptions return the options for creating the Sender.
+func (hook *Hook) deliveryOptions() (opts delivery.Options) {
+	opts = delivery.Options{
+		HTTPClient:         hook.opts.HTTPClient,
+		DropReport:         hook.dropReport,
+		Endpoint:           hook.opts.Endpoint,
+		Channel:            hook.opts.Channel,
+		Spool:              hook.opts.Spool,
+		Retry:              hook.opts.Retry,
+		RateLimit:          hook.opts.RateLimit,
+		RateBurst:          hook.opts.RateBurst,
+		Workers:            hook.opts.Workers,
+		QueueSize:          hook.opts.QueueSize,
+		Overflow:           hook.opts.Overflow,
+		OverflowLevel:      deliveryLevel(hook.opts.OverflowLevel),
+		DropReportInterval: hook.opts.DropReportInterval,
+	}
+	if hook.opts.OnError != nil {
+		opts.OnError = hook.onError
+	}
+	return opts
+}
+
+// post return the message as delivery.Post.
+func (hook *Hook) post(msg *Message) *delivery.Post {
+	return &delivery.Post{
+		Payload:  msg,
+		Endpoint: msg.endpoint,
+		Channel:  msg.channel,
+		Level:    deliveryLevel(msg.entryLevel),
+	}
+}
+
+// onError call the OnError in Options with the message of post that
+// failed to be send.
+// The post read from spool is converted into raw Message.
+func (hook *Hook) onError(post *delivery.Post, err error) {
+	msg, ok := post.Payload.(*Message)
+	if !ok {
+		raw, _ := post.Payload.MarshalJSON()
+		msg = newRawMessage(post.Endpoint, raw)
+	}
+	hook.opts.OnError(msg, err)
+}
+
+// dropReport return the post that report the number of dropped messages,
+// rendered using the formatter in Options.
+func (hook *Hook) dropReport(text string, total int64) *delivery.Post {
+	msg, err := hook.newMessage(hook.Channel(), hook.Username(),
+		&logrus.Entry{
+			Level:   logrus.WarnLevel,
+			Time:    time.Now(),
+			Message: text,
+			Data: logrus.Fields{
+				"dropped_total": total,
+			},
+		})
+	if err != nil {
+		return nil
+	}
+	return hook.post(msg)
+}
+
+// deliveryLevel convert the logrus level into delivery level.
+// Both have the same value for each level.
+func deliveryLevel(level logrus.Level) delivery.Level {
+	return delivery.Level(level)
+}
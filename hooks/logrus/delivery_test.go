@@ -0,0 +1,78 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/sirupsen/logrus"
+)
+
+// TestHookOnError test that the OnError in Options is called with the
+// Message that failed to be send.
+// The error itself is tested in package delivery.
+func TestHookOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusBadRequest)
+		}))
+	t.Cleanup(srv.Close)
+
+	var (
+		chanMsg = make(chan *Message, 1)
+		derr    *DeliveryError
+		gotErr  error
+	)
+
+	hook, err := New(Options{
+		Endpoint: srv.URL,
+		MinLevel: logrus.ErrorLevel,
+		OnError: func(msg *Message, err error) {
+			gotErr = err
+			chanMsg <- msg
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(hook.Stop)
+
+	err = hook.Fire(&logrus.Entry{
+		Level:   logrus.ErrorLevel,
+		Message: "failed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotPayload, _ := (<-chanMsg).MarshalJSON()
+	exp := `{"username":"` + _hostname + `","text":":exclamation: msg=failed"}`
+	assert(t, exp, string(gotPayload), true)
+
+	if !errors.As(gotErr, &derr) {
+		t.Fatalf("expecting *DeliveryError, got %T", gotErr)
+	}
+}
+
+func TestHookDropReport(t *testing.T) {
+	hook := &Hook{
+		hostname: _hostname,
+		opts:     Options{Channel: "ops"},
+	}
+	hook.opts.setDefault()
+
+	post := hook.dropReport("3 log messages were dropped in the last 20ms", 3)
+
+	assert(t, "ops", post.Channel, true)
+	assert(t, delivery.LevelWarning, post.Level, true)
+
+	got, _ := post.Payload.MarshalJSON()
+	exp := `{"channel":"ops","username":"` + _hostname + `","text":":interrobang: dropped_total=3 msg=3 log messages were dropped in the last 20ms"}`
+	assert(t, exp, string(got), true)
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// defCompactValueSize define the default maximum number of characters of
+// field value in CompactFormatter.
+const defCompactValueSize = 64
+
+// Formatter define the interface to convert the log entry into the content
+// of Mattermost post.
+//
+// The entry passed to Format does not contains the reserved fields, like
+// FieldChannel, since they are used by the hook to set the destination.
+type Formatter interface {
+	// Format return the text or the attachment of log entry.
+	// If the attachment is not nil, the post is send as attachment and
+	// the text is ignored.
+	Format(entry *logrus.Entry) (text string, attc *Attachment, err error)
+}
+
+// TextFormatter render the log entry as single line text in logfmt style,
+// followed by the error and multi-line field values in code block,
+//
+//	:icon: <field-key=field-value ...> [caller=file:line] msg=Message
+//
+// This is the default formatter if the Attachment in Options is nil.
+type TextFormatter struct {
+	// Caller define the options to render the caller information.
+	Caller CallerOptions
+
+	// CodeMaxLines define the maximum number of lines in code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+}
+
+// Format the log entry as text.
+func (f *TextFormatter) Format(entry *logrus.Entry) (
+	text string, attc *Attachment, err error,
+) {
+	msg := &Message{
+		entryData:    entry.Data,
+		entryLevel:   entry.Level,
+		entryMsg:     entry.Message,
+		codeMaxLines: codeMaxLines(f.CodeMaxLines),
+	}
+	msg.setCaller(entry, &f.Caller)
+
+	return msg.plainText(), nil, nil
+}
+
+// AttachmentFormatter render the log entry as attachment, with color
+// based on log level, text from the message, and fields from the entry
+// data.
+// See NewAttachment for more information.
+//
+// This is the default formatter if the Attachment in Options is not nil.
+type AttachmentFormatter struct {
+	// Attachment define the default attachment value.
+	Attachment *Attachment
+
+	// Caller define the options to render the caller information.
+	Caller CallerOptions
+
+	// CodeMaxLines define the maximum number of lines in code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+}
+
+// Format the log entry as attachment.
+func (f *AttachmentFormatter) Format(entry *logrus.Entry) (
+	text string, attc *Attachment, err error,
+) {
+	defAttc := f.Attachment
+	if defAttc == nil {
+		defAttc = &Attachment{}
+	}
+
+	msg := &Message{
+		attc: newAttachment(defAttc, entry, codeMaxLines(f.CodeMaxLines)),
+	}
+	msg.setCaller(entry, &f.Caller)
+
+	return "", msg.attc, nil
+}
+
+// TableFormatter render the log entry as the message line followed by
+// Markdown table of fields,
+//
+//	:icon: Message
+//
+//	| Field | Value |
+//	|:------|:------|
+//	| key   | value |
+//
+// The error and multi-line field values are rendered as code block after
+// the table.
+type TableFormatter struct {
+	// Caller define the options to render the caller information.
+	Caller CallerOptions
+
+	// CodeMaxLines define the maximum number of lines in code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+}
+
+// Format the log entry as text with Markdown table.
+func (f *TableFormatter) Format(entry *logrus.Entry) (
+	text string, attc *Attachment, err error,
+) {
+	var (
+		sb       strings.Builder
+		keys     = sortedKeys(entry.Data)
+		codeKeys []string
+		rows     [][2]string
+	)
+
+	for _, k := range keys {
+		value := record.FieldValue(entry.Data[k])
+		if record.IsCodeField(k, value) {
+			codeKeys = append(codeKeys, k)
+			continue
+		}
+		rows = append(rows, [2]string{k, value})
+	}
+
+	_, link := f.Caller.format(entry)
+	if len(link) > 0 {
+		rows = append(rows, [2]string{FieldCaller, link})
+	}
+
+	sb.WriteString(_iconsLevel[entry.Level])
+	if len(entry.Message) > 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(entry.Message)
+	}
+
+	if len(rows) > 0 {
+		sb.WriteString("\n\n| Field | Value |\n|:------|:------|")
+		for _, row := range rows {
+			sb.WriteString("\n| ")
+			sb.WriteString(tableEscape(row[0]))
+			sb.WriteString(" | ")
+			sb.WriteString(tableEscape(row[1]))
+			sb.WriteString(" |")
+		}
+	}
+
+	maxLines := codeMaxLines(f.CodeMaxLines)
+	for _, k := range codeKeys {
+		sb.WriteString("\n")
+		sb.WriteString(k)
+		sb.WriteString(":\n")
+		sb.WriteString(record.CodeBlock(record.FieldValue(entry.Data[k]), maxLines))
+	}
+
+	return sb.String(), nil, nil
+}
+
+// CompactFormatter render the log entry as one line text, where the
+// message and each field value is cut at the first line and truncated to
+// MaxValueSize characters,
+//
+//	:icon: Message key=value ...
+//
+// It is suitable for high volume channel where each post should take one
+// line.
+type CompactFormatter struct {
+	// MaxValueSize define the maximum number of characters of each
+	// field value.
+	// Default to 64.
+	MaxValueSize int
+}
+
+// Format the log entry as one line text.
+func (f *CompactFormatter) Format(entry *logrus.Entry) (
+	text string, attc *Attachment, err error,
+) {
+	var (
+		sb      strings.Builder
+		maxSize = f.MaxValueSize
+	)
+
+	if maxSize <= 0 {
+		maxSize = defCompactValueSize
+	}
+
+	sb.WriteString(_iconsLevel[entry.Level])
+	if len(entry.Message) > 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(firstLine(entry.Message))
+	}
+	for _, k := range sortedKeys(entry.Data) {
+		value := record.FieldValue(entry.Data[k])
+
+		sb.WriteByte(' ')
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(webhook.TruncateText(firstLine(value), maxSize))
+	}
+
+	return sb.String(), nil, nil
+}
+
+// codeMaxLines return the default number of lines in code block if `n`
+// is zero.
+func codeMaxLines(n int) int {
+	if n == 0 {
+		return record.DefCodeMaxLines
+	}
+	return n
+}
+
+// sortedKeys return the keys in `data` sorted in ascending order.
+func sortedKeys(data logrus.Fields) (keys []string) {
+	keys = make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// firstLine return the first line of `text`.
+// If the text has more than one line, the ellipsis is appended.
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	x := strings.IndexAny(text, "\r\n")
+	if x < 0 {
+		return text
+	}
+	return strings.TrimSpace(text[:x]) + webhook.Ellipsis
+}
+
+// tableEscape escape the pipe and the line break in the table cell.
+func tableEscape(cell string) string {
+	return strings.NewReplacer("|", `\|`, "\r", "", "\n", " ").Replace(cell)
+}
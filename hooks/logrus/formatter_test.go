@@ -0,0 +1,108 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFormatter(t *testing.T) {
+	var (
+		entry = &logrus.Entry{
+			Level:   logrus.WarnLevel,
+			Message: "disk almost full\nretrying",
+			Data: logrus.Fields{
+				"disk":          "/dev/sda|1",
+				"used":          "95%",
+				logrus.ErrorKey: errors.New("no space left"),
+			},
+		}
+		tests = []struct {
+			desc    string
+			f       Formatter
+			expText string
+			expAttc *Attachment
+		}{{
+			desc:    "TextFormatter",
+			f:       &TextFormatter{},
+			expText: ":interrobang: disk=/dev/sda|1 used=95% msg=disk almost full\nretrying\nerror:\n```\nno space left\n```",
+		}, {
+			desc: "AttachmentFormatter",
+			f: &AttachmentFormatter{
+				Attachment: &Attachment{Title: "app"},
+			},
+			expAttc: &Attachment{
+				Color: "#9F6000",
+				Text:  ":interrobang: disk almost full\nretrying",
+				Title: "app",
+				Fields: Fields{
+					{Short: true, Title: "disk", Value: "/dev/sda|1"},
+					{Short: true, Title: "used", Value: "95%"},
+					{Title: "error", Value: "```\nno space left\n```"},
+				},
+			},
+		}, {
+			desc:    "TableFormatter",
+			f:       &TableFormatter{},
+			expText: ":interrobang: disk almost full\nretrying\n\n| Field | Value |\n|:------|:------|\n| disk | /dev/sda\\|1 |\n| used | 95% |\nerror:\n```\nno space left\n```",
+		}, {
+			desc:    "CompactFormatter",
+			f:       &CompactFormatter{MaxValueSize: 8},
+			expText: ":interrobang: disk almost full… disk=/dev/sd… error=no spac… used=95%",
+		}}
+	)
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		gotText, gotAttc, err := test.f.Format(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.expText, gotText, true)
+		assert(t, test.expAttc, gotAttc, true)
+	}
+}
+
+// errFormatter is a Formatter that always return error.
+type errFormatter struct{}
+
+func (errFormatter) Format(*logrus.Entry) (string, *Attachment, error) {
+	return "", nil, errors.New("invalid entry")
+}
+
+func TestHookFormatter(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		Formatter: &CompactFormatter{},
+		MinLevel:  logrus.InfoLevel,
+	})
+
+	err := hook.Fire(&logrus.Entry{
+		Level:   logrus.InfoLevel,
+		Message: "started",
+		Data: logrus.Fields{
+			"port":       8080,
+			FieldChannel: "ops",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"channel":"ops","username":"` + _hostname + `","text":":white_circle: started port=8080"}`
+	assert(t, exp, <-chanBody, true)
+
+	hook, _ = newTestHook(t, Options{
+		Formatter: errFormatter{},
+		MinLevel:  logrus.InfoLevel,
+	})
+
+	err = hook.Fire(&logrus.Entry{Level: logrus.InfoLevel})
+	assert(t, "newMessage: invalid entry", err.Error(), true)
+}
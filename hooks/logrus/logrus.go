@@ -6,7 +6,10 @@
 //
 // Features:
 // - Asynchronous
-// - No level filter: all levels from logrus will be send to Mattermost.
+// - Filter log by level
+// - Multiple, independent hooks
+// - Route log by level and fields to different channels (see Route)
+// - Override channel, username, and icon per log entry (see FieldChannel)
 // - Sending log as message attachment (see NewHook)
 //
 // # Example
@@ -21,7 +24,8 @@
 //		_channel := "log_alpha"
 //		_username := "app-name"
 //
-//		logrus.AddHook(mmlogrus.NewHook(_endpoint, _channel, _username, nil))
+//		logrus.AddHook(mmlogrus.NewHook(_endpoint, _channel, _username,
+//			nil, logrus.DebugLevel))
 //
 //		logrus.WithFields(logrus.Fields{
 //			"k1": "v1",
@@ -30,113 +34,74 @@
 //
 //		mmlogrus.Stop()
 //	}
+//
+// # Multiple hooks
+//
+// Each call to New or NewHook create an independent Hook, with their own
+// configuration, HTTP client, queue, and consumer routine.
+// This allow several logrus.Logger to send the log to different webhook,
+//
+//	auditHook, err := mmlogrus.New(mmlogrus.Options{
+//		Endpoint: "https://my.mattermost.org/hooks/audit",
+//		Channel:  "audit",
+//		MinLevel: logrus.InfoLevel,
+//	})
+//	...
+//	auditLogger.AddHook(auditHook)
+//
+//	appHook, err := mmlogrus.New(mmlogrus.Options{
+//		Endpoint: "https://my.mattermost.org/hooks/app",
+//		MinLevel: logrus.ErrorLevel,
+//	})
+//	...
+//	appLogger.AddHook(appHook)
+//
+// # Flush and close
+//
+// Before the program exit, call Close on each Hook to wait for all queued
+// messages to be send.
+// The context passed to Close limit how long the program will wait,
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//
+//	dropped, err := appHook.Close(ctx)
+//	if err != nil {
+//		log.Printf("%d messages are not send: %s", dropped, err)
+//	}
+//
+// The queue, retry, rate limit, spool, and metrics are implemented by
+// package delivery, which is also used by the hooks for zap and zerolog.
 package logrus
 
-import (
-	"bytes"
-	"io/ioutil"
-	"net/http"
-	"time"
-)
-
-var (
-	_httpTr   *http.Transport
-	_httpCl   *http.Client
-	_chanMsg  chan *Message
-	_chanSent chan string
-	_running  bool
-)
-
-// send will send message `msg` to Mattermost.
-//
-// On success it will return the HTTP response body with nil error.
-// On fail it will return empty response with error message.
-func send(msg *Message) (sResBody string, err error) {
-	var (
-		reqBody, resBody []byte
-		body             *bytes.Reader
-		req              *http.Request
-		res              *http.Response
-	)
-
-	reqBody, err = msg.MarshalJSON()
-	if err != nil {
-		return
-	}
-
-	body = bytes.NewReader(reqBody)
-
-	req, err = http.NewRequest("POST", _hook.Endpoint(), body)
-	if err != nil {
-		return
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err = _httpCl.Do(req)
-	if err != nil {
-		return
-	}
+// Stop will wait for all message to be send and close all channels on
+// all hooks created by New or NewHook.
+func Stop() {
+	_hooksLocker.Lock()
+	hooks := _hooks
+	_hooks = nil
+	_hooksLocker.Unlock()
 
-	resBody, err = ioutil.ReadAll(res.Body)
-	if err == nil {
-		sResBody = string(resBody)
+	for _, hook := range hooks {
+		hook.Stop()
 	}
-
-	err = res.Body.Close()
-
-	return
 }
 
-// consumer will consume message from channel `_chanMsg` to be send to
-// Mattermost.
-func consumer() {
-	_running = true
-	for _running {
-		select {
-		case msg, ok := <-_chanMsg:
-			if !ok {
-				goto out
-			}
-
-			go func() {
-				res, err := send(msg)
-				if err != nil {
-					_chanSent <- err.Error()
-				} else {
-					_chanSent <- res
-				}
-			}()
-		case <-_chanSent:
-			continue
+// removeHook remove the `hook` from list of hooks that will be stopped by
+// Stop.
+func removeHook(hook *Hook) {
+	_hooksLocker.Lock()
+	for x, h := range _hooks {
+		if h == hook {
+			_hooks = append(_hooks[:x], _hooks[x+1:]...)
+			break
 		}
 	}
-out:
-	_running = false
-}
-
-// Stop will wait for all message to be send and close all channels.
-func Stop() {
-	_running = false
-	if _chanMsg != nil {
-		close(_chanMsg)
-	}
+	_hooksLocker.Unlock()
 }
 
 // Start will start the message consumer routine.
-func Start() {
-	_httpTr = &http.Transport{
-		MaxIdleConns:       3,
-		IdleConnTimeout:    time.Minute,
-		DisableCompression: false,
-	}
-
-	_httpCl = &http.Client{
-		Transport: _httpTr,
-	}
-
-	_chanMsg = make(chan *Message, 30)
-	_chanSent = make(chan string, 30)
-
-	go consumer()
-}
+//
+// Deprecated: each Hook start their own consumer routine when created by
+// New or NewHook, calling this function has no effect.
+func Start() {}
@@ -12,6 +12,10 @@ import (
 )
 
 func TestLogrusAddHook(t *testing.T) {
+	if len(_endpoint) == 0 {
+		t.Skip("environment variable " + envEndpointName + " is empty")
+	}
+
 	logrus.AddHook(NewHook(_endpoint, _channel, _username, nil,
 		logrus.TraceLevel))
 
@@ -40,13 +44,20 @@ func TestLogrusAddHook(t *testing.T) {
 	}).Error("A walrus error")
 }
 
-// TestMain will only run if user set the MM_HOOK_LOGRUS_ENDPOINT value in
-// environment.
+// TestMain will load the Mattermost endpoint from environment.
+// Test that send the log to the real Mattermost server will only run if user
+// set the MM_HOOK_LOGRUS_ENDPOINT value in environment.
 func TestMain(m *testing.M) {
 	_endpoint = os.Getenv(envEndpointName)
 	_channel = os.Getenv(envChannelName)
 	_username = os.Getenv(envUsernameName)
 
+	var err error
+	_hostname, err = os.Hostname()
+	if err != nil {
+		_hostname = os.Getenv("HOSTNAME")
+	}
+
 	println(">>> Mattermost endpoint: ", _endpoint)
 	println(">>> Mattermost channel : ", _channel)
 	println(">>> Mattermost username: ", _username)
@@ -54,8 +65,7 @@ func TestMain(m *testing.M) {
 
 	if len(_endpoint) == 0 {
 		println(">>> Environment variable " + envEndpointName + " is empty")
-		println(">>> No test will running.")
-		os.Exit(0)
+		println(">>> Test with Mattermost server will be skipped.")
 	}
 
 	s := m.Run()
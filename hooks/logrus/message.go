@@ -9,253 +9,351 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
 	"github.com/sirupsen/logrus"
 )
 
 // Message define the message that will be send to Mattermost.
 type Message struct {
-	attc       *Attachment
-	entryData  logrus.Fields
-	channel    string
-	username   string
-	hostname   string
-	entryMsg   string
-	dataKeys   []string
-	buf        bytes.Buffer
+	attc      *Attachment
+	entryData logrus.Fields
+	endpoint  string
+	channel   string
+	username  string
+	hostname  string
+	iconURL   string
+	entryMsg  string
+	caller    string
+	dataKeys  []string
+
+	// codeMaxLines define the maximum number of lines of field value
+	// rendered in code block.
+	codeMaxLines int
+
+	// text contains the rendered text of message, if its not empty it
+	// will be used instead of rendering the log entries.
+	text string
+
+	// raw contains the JSON payload of message read from spool.
+	raw []byte
+
+	// batch contains list of messages that will be send as single
+	// message.
+	batch []*Message
+
 	entryLevel logrus.Level
 }
 
+// List of reserved logrus fields to override the message destination per
+// log entry.
+// The reserved fields are not rendered in the message.
+const (
+	// FieldChannel override the channel where the log entry is send.
+	FieldChannel = record.FieldChannel
+
+	// FieldUsername override the user name that send the log entry.
+	FieldUsername = record.FieldUsername
+
+	// FieldIconURL override the profile picture of the log sender.
+	FieldIconURL = record.FieldIconURL
+)
+
 // NewMessage will create and return new Message.
+//
+// The channel, username, and icon URL can be overridden per log entry by
+// setting the reserved fields FieldChannel, FieldUsername, and
+// FieldIconURL in entry.Data.
 func NewMessage(channel, username, hostname string, attc *Attachment,
 	entry *logrus.Entry,
 ) (msg *Message) {
+	msg, entry = newMessageEntry(channel, username, hostname, entry)
+	msg.codeMaxLines = record.DefCodeMaxLines
+	msg.attc = NewAttachment(attc, entry)
+	msg.setCaller(entry, &CallerOptions{})
+	return msg
+}
+
+// newMessageEntry create new Message with the destination overridden by
+// the reserved fields in entry.
+// It return the message and the entry without the reserved fields.
+func newMessageEntry(channel, username, hostname string,
+	entry *logrus.Entry,
+) (msg *Message, stripped *logrus.Entry) {
 	msg = &Message{
-		attc:       NewAttachment(attc, entry),
+		channel:    channel,
+		username:   username,
+		hostname:   hostname,
 		entryData:  entry.Data,
 		entryLevel: entry.Level,
 		entryMsg:   entry.Message,
 	}
 
-	return
+	msg.setOverrides()
+
+	if len(msg.entryData) == len(entry.Data) {
+		return msg, entry
+	}
+
+	// Render the entry without the reserved fields.
+	copied := *entry
+	copied.Data = msg.entryData
+
+	return msg, &copied
 }
 
-func (msg *Message) generateDataKeys() {
-	msg.dataKeys = nil
+// setOverrides set the message destination from the reserved fields in
+// entry data, and remove them from the data.
+// The original entry data is not modified.
+func (msg *Message) setOverrides() {
+	var (
+		data     logrus.Fields
+		channel  = fieldString(msg.entryData, FieldChannel)
+		username = fieldString(msg.entryData, FieldUsername)
+		iconURL  = fieldString(msg.entryData, FieldIconURL)
+	)
+
+	if channel == nil && username == nil && iconURL == nil {
+		return
+	}
 
-	for k := range msg.entryData {
-		msg.dataKeys = append(msg.dataKeys, k)
+	data = make(logrus.Fields, len(msg.entryData))
+	for k, v := range msg.entryData {
+		switch k {
+		case FieldChannel, FieldUsername, FieldIconURL:
+		default:
+			data[k] = v
+		}
 	}
+	msg.entryData = data
 
-	sort.Strings(msg.dataKeys)
+	if channel != nil {
+		msg.channel = *channel
+	}
+	if username != nil {
+		msg.username = *username
+	}
+	if iconURL != nil {
+		msg.iconURL = *iconURL
+	}
 }
 
-// getText will convert Message into text. The text output format,
-//
-// `:icon: <field-key=field-value ...> msg=Message`
-func (msg Message) getText() (str string) {
-	var out []byte
-
-	out = append(out, []byte(_iconsLevel[msg.entryLevel])...)
+// fieldString return the value of field `key` in `data` as string, or nil
+// if the field is not exist.
+func fieldString(data logrus.Fields, key string) *string {
+	v, ok := data[key]
+	if !ok {
+		return nil
+	}
+	str := fmt.Sprintf("%v", v)
+	return &str
+}
 
-	msg.generateDataKeys()
+// newRawMessage create new Message from JSON `payload` that will be send
+// to `endpoint`.
+func newRawMessage(endpoint string, payload []byte) (msg *Message) {
+	return &Message{
+		endpoint: endpoint,
+		raw:      append([]byte(nil), payload...),
+	}
+}
 
-	for _, k := range msg.dataKeys {
-		out = append(out, ' ')
-		out = append(out, []byte(k)...)
-		out = append(out, '=')
-
-		str = fmt.Sprintf("%+v", msg.entryData[k])
-		for _, c := range []byte(str) {
-			if c == '\\' {
-				out = append(out, []byte(`\`)...)
-				out = append(out, []byte(`\`)...)
-				continue
-			}
-			if c == '"' {
-				out = append(out, []byte(`\`)...)
-				out = append(out, []byte(`"`)...)
-				continue
-			}
-			out = append(out, c)
+// newBatchMessage create new Message that combine list of messages `msgs`
+// into single message.
+// The channel and username is taken from the first message, and the level
+// is set to the most severe level in `msgs`.
+func newBatchMessage(msgs []*Message) (msg *Message) {
+	msg = &Message{
+		endpoint:   msgs[0].endpoint,
+		channel:    msgs[0].channel,
+		username:   msgs[0].username,
+		hostname:   msgs[0].hostname,
+		iconURL:    msgs[0].iconURL,
+		batch:      msgs,
+		entryLevel: msgs[0].entryLevel,
+	}
+	for _, sub := range msgs[1:] {
+		if sub.entryLevel < msg.entryLevel {
+			msg.entryLevel = sub.entryLevel
 		}
 	}
+	return msg
+}
+
+// destination return the identity of message destination.
+func (msg *Message) destination() string {
+	return msg.endpoint + "\x00" + msg.channel + "\x00" + msg.username +
+		"\x00" + msg.iconURL
+}
 
-	if len(msg.entryMsg) > 0 {
-		out = append(out, ' ')
-		out = append(out, []byte("msg=")...)
-
-		for _, c := range []byte(msg.entryMsg) {
-			if c == '\\' {
-				out = append(out, []byte(`\`)...)
-				out = append(out, []byte(`\`)...)
-				continue
-			}
-			if c == '"' {
-				out = append(out, []byte(`\`)...)
-				out = append(out, []byte(`"`)...)
-				continue
-			}
-			out = append(out, c)
+// attachments return list of attachment in message.
+func (msg *Message) attachments() (list []*Attachment) {
+	if len(msg.batch) == 0 {
+		if msg.attc != nil {
+			list = append(list, msg.attc)
 		}
+		return list
 	}
-
-	return string(out)
+	for _, sub := range msg.batch {
+		if sub.attc != nil {
+			list = append(list, sub.attc)
+		}
+	}
+	return list
 }
 
-// _marshalJSON will convert message to JSON.
-// NOTE: unused
-func (msg *Message) _marshalJSON() (out []byte, err error) {
-	str := `{`
+func (msg *Message) generateDataKeys() {
+	msg.dataKeys = nil
 
-	if len(msg.channel) > 0 {
-		str += `"channel":"` + msg.channel + `",`
+	for k := range msg.entryData {
+		msg.dataKeys = append(msg.dataKeys, k)
 	}
 
-	if len(msg.username) > 0 {
-		str += `"username":"` + msg.username + `",`
-	} else {
-		str += `"username":"` + msg.hostname + `",`
+	sort.Strings(msg.dataKeys)
+}
+
+// plainText return the message as text, without escaped.
+// For batch message, each message is written on its own line.
+// The text output format,
+//
+// `:icon: <field-key=field-value ...> [caller=file:line] msg=Message`
+// `<field-key>:`
+// "```"
+// `<multi-line field-value>`
+// "```"
+func (msg *Message) plainText() string {
+	if len(msg.text) > 0 {
+		return msg.text
 	}
 
-	if msg.attc != nil {
-		var attc []byte
+	var buf bytes.Buffer
 
-		attc, err = msg.attc.MarshalJSON()
-		if err != nil {
-			return
+	if len(msg.batch) == 0 {
+		_ = msg.writeTextLine(&buf)
+	}
+	for x, sub := range msg.batch {
+		if x > 0 {
+			_ = buf.WriteByte('\n')
 		}
-
-		str += `"attachments":[` + string(attc) + `]`
-	} else {
-		str += `"text":"` + msg.getText() + `"`
+		_, _ = buf.WriteString(sub.plainText())
 	}
 
-	str += `}`
-	out = []byte(str)
-
-	return
+	return buf.String()
 }
 
-func (msg *Message) writeEntryData() (err error) {
+// writeEntryData write the single line field values as "key=value".
+// The field that should be rendered as code block is collected into
+// `codeKeys`.
+func (msg *Message) writeEntryData(buf *bytes.Buffer) (
+	codeKeys []string, err error,
+) {
 	msg.generateDataKeys()
 
 	for _, k := range msg.dataKeys {
-		err = msg.buf.WriteByte(' ')
+		value := record.FieldValue(msg.entryData[k])
+		if record.IsCodeField(k, value) {
+			codeKeys = append(codeKeys, k)
+			continue
+		}
+		_, err = fmt.Fprintf(buf, " %s=%s", k, value)
 		if err != nil {
 			return
 		}
+	}
 
-		str := fmt.Sprintf("%+v", msg.entryData[k])
+	return
+}
 
-		err = bufWriteKV(&msg.buf, k, []byte(str), '=', 0, 0)
+// writeEntryCode write each field in `keys` as code block after the
+// summary line.
+func (msg *Message) writeEntryCode(buf *bytes.Buffer, keys []string) (
+	err error,
+) {
+	for _, k := range keys {
+		_, err = fmt.Fprintf(buf, "\n%s:\n%s", k,
+			record.CodeBlock(record.FieldValue(msg.entryData[k]), msg.codeMaxLines))
 		if err != nil {
 			return
 		}
 	}
-
 	return
 }
 
-func (msg *Message) writeEntryMsg() (err error) {
+func (msg *Message) writeEntryMsg(buf *bytes.Buffer) (err error) {
 	if len(msg.entryMsg) == 0 {
 		return
 	}
 
-	err = msg.buf.WriteByte(' ')
+	_, err = buf.WriteString(" msg=")
 	if err != nil {
 		return
 	}
 
-	err = bufWriteKV(&msg.buf, "msg", []byte(msg.entryMsg), '=', 0, 0)
-	if err != nil {
-		return
-	}
+	_, err = buf.WriteString(msg.entryMsg)
 
 	return
 }
 
-func (msg *Message) writeText() (err error) {
-	_, err = msg.buf.WriteString(`"text":"`)
+// writeTextLine write the message into `buf` as single line text, without
+// escaped.
+// The error and multi-line field values are written as code block after
+// the line.
+func (msg *Message) writeTextLine(buf *bytes.Buffer) (err error) {
+	_, err = buf.WriteString(_iconsLevel[msg.entryLevel])
 	if err != nil {
 		return
 	}
 
-	_, err = msg.buf.WriteString(_iconsLevel[msg.entryLevel])
+	codeKeys, err := msg.writeEntryData(buf)
 	if err != nil {
 		return
 	}
 
-	err = msg.writeEntryData()
-	if err != nil {
-		return
+	if len(msg.caller) > 0 {
+		_, err = buf.WriteString(" " + FieldCaller + "=" + msg.caller)
+		if err != nil {
+			return
+		}
 	}
 
-	err = msg.writeEntryMsg()
+	err = msg.writeEntryMsg(buf)
 	if err != nil {
 		return
 	}
 
-	err = msg.buf.WriteByte('"')
-
-	return
+	return msg.writeEntryCode(buf, codeKeys)
 }
 
-// marshalJSON will convert message to JSON.
-func (msg *Message) MarshalJSON() (out []byte, err error) {
-	msg.buf.Reset()
-
-	err = msg.buf.WriteByte('{')
-	if err != nil {
-		return
-	}
-
-	if len(msg.channel) > 0 {
-		err = bufWriteKV(&msg.buf, `"channel"`, []byte(msg.channel),
-			':', '"', '"')
-		if err != nil {
-			return
-		}
-		err = msg.buf.WriteByte(',')
-		if err != nil {
-			return
-		}
+// payload return the Message as webhook.Payload.
+// If the username is empty, the hostname is used.
+func (msg *Message) payload() (payload webhook.Payload) {
+	payload = webhook.Payload{
+		Channel:  msg.channel,
+		Username: msg.username,
+		IconURL:  msg.iconURL,
 	}
-	if len(msg.username) > 0 {
-		err = bufWriteKV(&msg.buf, `"username"`, []byte(msg.username),
-			':', '"', '"')
-	} else {
-		err = bufWriteKV(&msg.buf, `"username"`, []byte(msg.hostname),
-			':', '"', '"')
-	}
-	if err != nil {
-		return
-	}
-	err = msg.buf.WriteByte(',')
-	if err != nil {
-		return
+	if len(payload.Username) == 0 {
+		payload.Username = msg.hostname
 	}
 
-	if msg.attc != nil {
-		var attc []byte
-
-		attc, err = msg.attc.MarshalJSON()
-		if err != nil {
-			return
-		}
-
-		_, _ = msg.buf.WriteString(`"attachments":[`)
-		_, _ = msg.buf.Write(attc)
-		_ = msg.buf.WriteByte(']')
-	} else {
-		err = msg.writeText()
+	list := msg.attachments()
+	if len(list) == 0 {
+		payload.Text = msg.plainText()
+		return payload
 	}
 
-	if err != nil {
-		return
+	payload.Attachments = make([]*webhook.Attachment, 0, len(list))
+	for _, attc := range list {
+		payload.Attachments = append(payload.Attachments,
+			(*webhook.Attachment)(attc))
 	}
+	return payload
+}
 
-	err = msg.buf.WriteByte('}')
-	out = msg.buf.Bytes()
-
-	return
+// MarshalJSON will convert message to JSON.
+func (msg *Message) MarshalJSON() (out []byte, err error) {
+	if len(msg.raw) > 0 {
+		return msg.raw, nil
+	}
+	return msg.payload().MarshalJSON()
 }
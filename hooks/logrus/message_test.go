@@ -1,8 +1,12 @@
 package logrus
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,9 +35,9 @@ func newMessage() *Message {
 	}
 }
 
-func BenchmarkMarshalJSONOld(b *testing.B) {
+func BenchmarkMarshalJSONBuffer(b *testing.B) {
 	msg := newMessage()
-	got, err := msg._marshalJSON()
+	got, err := msg.MarshalJSON()
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -43,22 +47,166 @@ func BenchmarkMarshalJSONOld(b *testing.B) {
 	assertb(b, expMsgJSON, got, true)
 
 	for x := 0; x < b.N; x++ {
-		_, _ = msg._marshalJSON()
+		_, _ = msg.MarshalJSON()
 	}
 }
 
-func BenchmarkMarshalJSONBuffer(b *testing.B) {
-	msg := newMessage()
-	got, err := msg.MarshalJSON()
-	if err != nil {
-		b.Fatal(err)
+func FuzzMessageMarshalJSON(f *testing.F) {
+	f.Add("town-square", "bot", "key", "value", "message")
+	f.Add("", "", "err", "line 1\n\tline 2", "panic: \"nil\"\r\n")
+	f.Add("ch\x00", "user\xff", "k\"=", "v\\", "\x1b[31mred\x1b[0m")
+
+	f.Fuzz(func(t *testing.T, channel, username, key, value, text string) {
+		var (
+			msg = &Message{
+				channel:    channel,
+				username:   username,
+				entryLevel: logrus.InfoLevel,
+				entryData:  logrus.Fields{key: value},
+				entryMsg:   text,
+			}
+			got struct {
+				Channel  string
+				Username string
+				Text     string
+			}
+		)
+
+		out, err := msg.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = json.Unmarshal(out, &got)
+		if err != nil {
+			t.Fatalf("%q: %s", out, err)
+		}
+
+		assert(t, string([]rune(channel)), got.Channel, true)
+		assert(t, string([]rune(username)), got.Username, true)
+
+		var code string
+		if record.IsCodeField(key, value) {
+			code = string([]rune("\n" + key + ":\n" + record.CodeBlock(value, 0)))
+			if !strings.HasSuffix(got.Text, code) {
+				t.Fatalf("expecting %q in text %q", code, got.Text)
+			}
+		} else {
+			exp := " " + string([]rune(key)) + "=" + string([]rune(value))
+			if !strings.Contains(got.Text, exp) {
+				t.Fatalf("expecting %q in text %q", exp, got.Text)
+			}
+		}
+		if len(text) > 0 {
+			exp := " msg=" + string([]rune(text)) + code
+			if !strings.HasSuffix(got.Text, exp) {
+				t.Fatalf("expecting %q in text %q", exp, got.Text)
+			}
+		}
+	})
+}
+
+func TestNewMessage(t *testing.T) {
+	var attc = &Attachment{
+		Pretext: "pretext",
 	}
 
-	b.Log(string(got))
+	tests := []struct {
+		entry *logrus.Entry
+		attc  *Attachment
+		desc  string
+		exp   string
+	}{
+		{
+			desc: "With default destination",
+			entry: &logrus.Entry{
+				Level:   logrus.InfoLevel,
+				Message: "started",
+				Data:    logrus.Fields{"k": "v"},
+			},
+			exp: `{"channel":"logs","username":"app","text":":white_circle: k=v msg=started"}`,
+		},
+		{
+			desc: "With overrides",
+			entry: &logrus.Entry{
+				Level:   logrus.InfoLevel,
+				Message: "paid",
+				Data: logrus.Fields{
+					FieldChannel:  "billing",
+					FieldUsername: "billing-bot",
+					FieldIconURL:  "https://example.com/icon.png",
+					"k":           "v",
+				},
+			},
+			exp: `{"channel":"billing","username":"billing-bot","icon_url":"https://example.com/icon.png","text":":white_circle: k=v msg=paid"}`,
+		},
+		{
+			desc: "With overrides in attachment",
+			entry: &logrus.Entry{
+				Level:   logrus.InfoLevel,
+				Message: "paid",
+				Data: logrus.Fields{
+					FieldChannel: "billing",
+					"k":          "v",
+				},
+			},
+			attc: attc,
+			exp:  `{"channel":"billing","username":"app","attachments":[{"color":"#FFFFFF","fields":[{"short":true,"title":"k","value":"v"}],"pretext":"pretext","text":":white_circle: paid"}]}`,
+		},
+	}
 
-	assertb(b, expMsgJSON, got, true)
+	for _, test := range tests {
+		t.Log(test.desc)
 
-	for x := 0; x < b.N; x++ {
-		_, _ = msg.MarshalJSON()
+		msg := NewMessage("logs", "app", "localhost", test.attc, test.entry)
+
+		got, err := msg.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, string(got), true)
+	}
+
+	// The original entry data should not be modified.
+	assert(t, 4, len(tests[1].entry.Data), true)
+}
+
+func TestMessageCodeField(t *testing.T) {
+	var (
+		entry = &logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Message: "query failed",
+			Data: logrus.Fields{
+				logrus.ErrorKey: errors.New("timeout"),
+				"id":            1,
+				"query":         "SELECT *\nFROM t",
+			},
+		}
+		tests = []struct {
+			desc string
+			attc *Attachment
+			exp  string
+		}{{
+			desc: "With text",
+			exp:  `{"username":"bot","text":":exclamation: id=1 msg=query failed\nerror:\n` + "```" + `\ntimeout\n` + "```" + `\nquery:\n` + "```" + `\nSELECT *\nFROM t\n` + "```" + `"}`,
+		}, {
+			desc: "With attachment",
+			attc: &Attachment{},
+			exp:  `{"username":"bot","attachments":[{"color":"#990000","fields":[{"short":true,"title":"id","value":"1"},{"short":false,"title":"error","value":"` + "```" + `\ntimeout\n` + "```" + `"},{"short":false,"title":"query","value":"` + "```" + `\nSELECT *\nFROM t\n` + "```" + `"}],"text":":exclamation: query failed"}]}`,
+		}}
+	)
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		msg := NewMessage("", "bot", "", test.attc, entry)
+
+		got, err := msg.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, string(got), true)
 	}
 }
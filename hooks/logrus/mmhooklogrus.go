@@ -5,44 +5,128 @@
 package logrus
 
 import (
+	"context"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/internal/level"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// defFlushTimeout define the default maximum duration to wait for
+	// queued messages before logrus exit or panic.
+	defFlushTimeout = 5 * time.Second
+
+	// defStopTimeout define the maximum duration to wait for queued
+	// messages when calling Stop.
+	defStopTimeout = 30 * time.Second
+)
+
+// ErrClosed define an error when sending log to Hook that has been closed.
+// It is the same error as delivery.ErrClosed.
+var ErrClosed = delivery.ErrClosed
+
+// ErrSpooled define an error when the Hook closed with messages that has
+// not been send, but they are kept in spool to be send on the next start.
+// It is the same error as delivery.ErrSpooled.
+var ErrSpooled = delivery.ErrSpooled
+
 var (
 	//
-	// _hook contain a singleton instance of `mmHookLogrus`. The
-	// subsequence call of `NewHook` will only replace the field value.
+	// _hooks contains list of Hook that has been created by New or
+	// NewHook, so all of them can be stopped by calling Stop.
 	//
-	_hook       *mmHookLogrus
-	_hookLocker sync.Mutex
+	_hooks       []*Hook
+	_hooksLocker sync.Mutex
 
 	//
 	// _iconsLevel contains list of icon to be displayed before log
 	// message based on log level.
 	//
-	_iconsLevel = []string{
-		":x:",            // Panic
-		":bangbang:",     // Fatal
-		":exclamation:",  // Error
-		":interrobang:",  // Warning
-		":white_circle:", // Info
-		":black_circle:", // Debug
-		":mag_right:",    // Trace
-	}
+	_iconsLevel = level.Icons
 )
 
-// mmHookLogrus contains configuration for Mattermost (server address,
-// channel, username) and reusable http transport and client.
-type mmHookLogrus struct {
-	endpoint string
-	channel  string
-	username string
-	defAttc  *Attachment
+// Hook contains configuration for Mattermost (server address, channel,
+// username) and the Sender that queue and send the messages.
+//
+// Each Hook is independent from each other: they have their own
+// configuration, HTTP client, queue, and consumer routine.
+type Hook struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	sender   *delivery.Sender
 	hostname string
+	opts     Options
 	levels   []logrus.Level
+
+	// batches contains the messages that will be send as single
+	// message, indexed by its destination.
+	// The closed is set by Close, so no new batch is created after the
+	// hook has been closed.
+	batches     map[string]*batchQueue
+	batchLocker sync.Mutex
+	closed      bool
+
+	// dedupEntries contains the state of repeated log entries, indexed
+	// by its fingerprint.
+	dedupEntries map[string]*dedupEntry
+	dedupLocker  sync.Mutex
+
+	// mutes contains the expiration time of muted fingerprint.
+	mutes      map[string]time.Time
+	muteLocker sync.Mutex
+}
+
+// New create and start new Hook using the configuration from Options.
+func New(opts Options) (hook *Hook, err error) {
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	err = opts.initTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	return newHook(opts)
+}
+
+// newHook create and start new Hook without validating the Options.
+func newHook(opts Options) (hook *Hook, err error) {
+	opts.setDefault()
+
+	hook = &Hook{
+		opts:    opts,
+		levels:  opts.levels(),
+		batches: make(map[string]*batchQueue),
+		mutes:   make(map[string]time.Time),
+	}
+
+	hook.hostname, err = os.Hostname()
+	if err != nil {
+		hook.hostname = os.Getenv("HOSTNAME")
+	}
+
+	hook.sender, err = delivery.New(hook.deliveryOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	hook.ctx, hook.cancel = context.WithCancel(context.Background())
+
+	_hooksLocker.Lock()
+	_hooks = append(_hooks, hook)
+	_hooksLocker.Unlock()
+
+	if opts.Dedup.Window > 0 {
+		hook.dedupEntries = make(map[string]*dedupEntry)
+		go hook.dedupSweeper()
+	}
+
+	return hook, nil
 }
 
 // NewHook will create a log hook for mattermost. The log will be send to
@@ -57,108 +141,183 @@ type mmHookLogrus struct {
 // [1].  The parameter will act as default attachment value, and it will
 // replace the `Text` with `Entry.Message` and `Fields` with `Entry.Data`.
 //
+// Each call to NewHook will create new, independent Hook.
+// Use New for more options.
+//
 // [1] https://docs.mattermost.com/developer/message-attachments.html
 func NewHook(endpoint, channel, username string, attc *Attachment, minLevel logrus.Level) logrus.Hook {
-	levels := make([]logrus.Level, 0, len(logrus.AllLevels))
-	for _, lvl := range logrus.AllLevels { // sorted reversally
-		if lvl <= minLevel {
-			levels = append(levels, lvl)
-		}
+	var opts = Options{
+		Endpoint:   endpoint,
+		Channel:    channel,
+		Username:   username,
+		Attachment: attc,
+		MinLevel:   minLevel,
 	}
 
-	var err error
+	// The error only returned when opening the spool, which is not
+	// used by NewHook.
+	hook, _ := newHook(opts)
+
+	return hook
+}
+
+// Levels will return all logrus level that will be send to Mattermost.
+func (hook *Hook) Levels() []logrus.Level {
+	return hook.levels
+}
+
+// Fire will send logrus `entry` to Mattermost.
+//
+// If the entry level is panic or fatal, it will wait until all queued
+// messages has been send or the FlushTimeout in Options, since logrus
+// will panic or exit the program after Fire return.
+//
+// It will return ErrClosed if the hook has been closed.
+func (hook *Hook) Fire(entry *logrus.Entry) (err error) {
+	if entry == nil {
+		return
+	}
 
-	if _hook == nil {
-		_hook = &mmHookLogrus{
-			endpoint: endpoint,
-			channel:  channel,
-			username: username,
-			defAttc:  attc,
-			levels:   levels,
+	for _, lvl := range hook.levels {
+		if lvl != entry.Level {
+			continue
 		}
 
-		_hook.hostname, err = os.Hostname()
+		err = hook.fire(entry)
 		if err != nil {
-			_hook.hostname = os.Getenv("HOSTNAME")
+			return err
+		}
+		if entry.Level <= logrus.FatalLevel {
+			err = hook.flush()
 		}
-	} else {
-		_hookLocker.Lock()
+		return err
+	}
 
-		_hook.endpoint = endpoint
-		_hook.channel = channel
-		_hook.username = username
-		_hook.defAttc = attc
-		_hook.levels = levels
+	return
+}
 
-		_hookLocker.Unlock()
+// fire send the `entry` that is not muted or repeated.
+func (hook *Hook) fire(entry *logrus.Entry) (err error) {
+	fp := hook.Fingerprint(entry)
+	if hook.isMuted(fp, time.Now()) {
+		return nil
 	}
-
-	if !_running {
-		Start()
+	if hook.opts.Dedup.Window > 0 && !hook.dedup(entry, fp) {
+		return nil
 	}
-
-	return _hook
+	return hook.route(entry, fp)
 }
 
-// Levels will return all logrus level that will be send to Mattermost.
-func (hook *mmHookLogrus) Levels() []logrus.Level {
-	return hook.levels
+// flush wait until all queued messages has been send, limited by
+// FlushTimeout in Options.
+func (hook *Hook) flush() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		hook.opts.FlushTimeout)
+	defer cancel()
+
+	_, err = hook.Flush(ctx)
+	return err
 }
 
-// Fire will send logrus `entry` to Mattermost.
-func (hook *mmHookLogrus) Fire(entry *logrus.Entry) (err error) {
-	if entry == nil {
-		return
+// submit push the message into batch, if its enabled, or into queue.
+func (hook *Hook) submit(msg *Message) error {
+	if hook.opts.Batch.enabled() {
+		return hook.batchAdd(msg)
 	}
+	return hook.enqueue(msg)
+}
 
-	for _, lvl := range hook.levels {
-		if lvl == entry.Level {
-			msg := NewMessage(hook.Channel(), hook.Username(), hook.Hostname(),
-				hook.Attachment(), entry)
-			_chanMsg <- msg
-			break
+// enqueue push the message into queue, split or truncated based on the
+// oversize options.
+func (hook *Hook) enqueue(msg *Message) (err error) {
+	for _, part := range msg.fitSize(&hook.opts.Oversize) {
+		err = hook.enqueueMessage(part)
+		if err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// enqueueMessage push the message into queue of Sender.
+func (hook *Hook) enqueueMessage(msg *Message) (err error) {
+	return hook.sender.Push(hook.post(msg))
+}
+
+// Flush send the current batch, if any, and wait until all queued
+// messages has been send to Mattermost or the context `ctx` is done.
+//
+// On success it will return zero with nil error.
+// If the context is done before all messages has been send, it will
+// return the number of messages that are still queued or being send, with
+// the context error.
+func (hook *Hook) Flush(ctx context.Context) (n int, err error) {
+	hook.batchFlush()
+	return hook.sender.Flush(ctx)
+}
+
+// Close stop receiving new log and wait until all queued messages has
+// been send to Mattermost or the context `ctx` is done.
+// The report of repeated log entries and the current batch, if any, are
+// send before closing.
+// Any call to Fire after Close will return ErrClosed, and the hook is
+// removed from the list of hooks that will be stopped by Stop.
+//
+// If the context is done before all messages has been send, all of the
+// outstanding requests will be cancelled and it will return the number of
+// messages that are dropped, with the context error.
+// If the spool is enabled, Close does not wait for the message that failed
+// temporarily to be retried; the messages that has not been send are kept
+// in the spool, to be send on the next start, and it will return the
+// number of them with ErrSpooled.
+func (hook *Hook) Close(ctx context.Context) (dropped int, err error) {
+	if hook.opts.Dedup.Window > 0 {
+		hook.dedupSweep(time.Now(), true)
+	}
 
-	return
+	hook.batchLocker.Lock()
+	hook.closed = true
+	hook.batchLocker.Unlock()
+	hook.batchFlush()
+
+	removeHook(hook)
+
+	dropped, err = hook.sender.Close(ctx)
+	hook.cancel()
+
+	return dropped, err
+}
+
+// Stop will wait for all message to be send and close the hook.
+// It is equal to calling Close with 30 seconds deadline.
+func (hook *Hook) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), defStopTimeout)
+	defer cancel()
+
+	_, _ = hook.Close(ctx)
 }
 
 // Endpoint will return Mattermost endpoint defined in hook.
-func (hook *mmHookLogrus) Endpoint() (endpoint string) {
-	_hookLocker.Lock()
-	endpoint = hook.endpoint
-	_hookLocker.Unlock()
-	return
+func (hook *Hook) Endpoint() string {
+	return hook.opts.Endpoint
 }
 
 // Channel will return Mattermost channel defined in hook.
-func (hook *mmHookLogrus) Channel() (channel string) {
-	_hookLocker.Lock()
-	channel = hook.channel
-	_hookLocker.Unlock()
-	return
+func (hook *Hook) Channel() string {
+	return hook.opts.Channel
 }
 
 // Username will return Mattermost username defined in hook.
-func (hook *mmHookLogrus) Username() (username string) {
-	_hookLocker.Lock()
-	username = hook.username
-	_hookLocker.Unlock()
-	return
+func (hook *Hook) Username() string {
+	return hook.opts.Username
 }
 
 // Attachment will return default Mattermost attachment defined in hook.
-func (hook *mmHookLogrus) Attachment() (attc *Attachment) {
-	_hookLocker.Lock()
-	attc = hook.defAttc
-	_hookLocker.Unlock()
-	return
+func (hook *Hook) Attachment() *Attachment {
+	return hook.opts.Attachment
 }
 
 // Hostname will return hostname of current hook.
-func (hook *mmHookLogrus) Hostname() (hostname string) {
-	_hookLocker.Lock()
-	hostname = hook.hostname
-	_hookLocker.Unlock()
-	return
+func (hook *Hook) Hostname() string {
+	return hook.hostname
 }
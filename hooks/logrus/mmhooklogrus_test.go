@@ -5,10 +5,15 @@
 package logrus
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"runtime/debug"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -21,6 +26,10 @@ const (
 
 var (
 	_endpoint, _channel, _username string
+
+	// _hostname contains the host name used as default username in
+	// message.
+	_hostname string
 )
 
 func assertb(b *testing.B, exp, got interface{}, equal bool) {
@@ -43,8 +52,215 @@ func assert(t *testing.T, exp, got interface{}, equal bool) {
 	}
 }
 
+// newTestServer create new HTTP server that forward each request body
+// into channel `chanBody`.
+func newTestServer() (srv *httptest.Server, chanBody chan string) {
+	chanBody = make(chan string, 30)
+
+	srv = httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			chanBody <- string(body)
+			_, _ = res.Write([]byte("ok"))
+		}))
+
+	return srv, chanBody
+}
+
+// newTestHook create new Hook that send the message to test server.
+func newTestHook(t *testing.T, opts Options) (hook *Hook, chanBody chan string) {
+	var (
+		srv *httptest.Server
+		err error
+	)
+
+	srv, chanBody = newTestServer()
+	t.Cleanup(srv.Close)
+
+	opts.Endpoint = srv.URL
+
+	hook, err = New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(hook.Stop)
+
+	return hook, chanBody
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{})
+	assert(t, ErrEndpointEmpty, err, true)
+
+	hookAudit, chanAudit := newTestHook(t, Options{
+		MinLevel: logrus.InfoLevel,
+	})
+	hookApp, chanApp := newTestHook(t, Options{
+		MinLevel: logrus.ErrorLevel,
+	})
+
+	logAudit := logrus.New()
+	logAudit.SetOutput(io.Discard)
+	logAudit.AddHook(hookAudit)
+
+	logApp := logrus.New()
+	logApp.SetOutput(io.Discard)
+	logApp.AddHook(hookApp)
+
+	logApp.Info("info app")
+	logAudit.Info("info audit")
+	logApp.Error("error app")
+
+	assert(t, `{"username":"`+_hostname+`","text":":white_circle: msg=info audit"}`,
+		<-chanAudit, true)
+	assert(t, `{"username":"`+_hostname+`","text":":exclamation: msg=error app"}`,
+		<-chanApp, true)
+
+	select {
+	case got := <-chanAudit:
+		t.Fatalf("unexpected message on audit hook: %s", got)
+	case got := <-chanApp:
+		t.Fatalf("unexpected message on app hook: %s", got)
+	default:
+	}
+}
+
+func TestHookFlush(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		MinLevel: logrus.InfoLevel,
+	})
+
+	for _, msg := range []string{"one", "two", "three"} {
+		err := hook.Fire(&logrus.Entry{
+			Level:   logrus.InfoLevel,
+			Message: msg,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := hook.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, 0, n, true)
+	assert(t, 3, len(chanBody), true)
+}
+
+func TestHookClose(t *testing.T) {
+	chanRelease := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			select {
+			case <-chanRelease:
+			case <-req.Context().Done():
+			}
+		}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(chanRelease) })
+
+	hook, err := New(Options{
+		Endpoint: srv.URL,
+		MinLevel: logrus.InfoLevel,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &logrus.Entry{
+		Level:   logrus.InfoLevel,
+		Message: "blocked",
+	}
+
+	for x := 0; x < 2; x++ {
+		err = hook.Fire(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+
+	dropped, err := hook.Close(ctx)
+
+	assert(t, context.DeadlineExceeded, err, true)
+	assert(t, 2, dropped, true)
+	assert(t, ErrClosed, hook.Fire(entry), true)
+
+	// Calling Close or Stop after the hook has been closed should not
+	// panic.
+	hook.Stop()
+}
+
+func TestHookFireExit(t *testing.T) {
+	tests := []struct {
+		desc  string
+		batch BatchOptions
+	}{{
+		desc: "Without batch",
+	}, {
+		desc: "With batch",
+		batch: BatchOptions{
+			Size:   10,
+			Window: time.Hour,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		hook, chanBody := newTestHook(t, Options{
+			MinLevel: logrus.InfoLevel,
+			Batch:    test.batch,
+		})
+
+		var got int
+
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		logger.AddHook(hook)
+		logger.ExitFunc = func(int) {
+			got = len(chanBody)
+		}
+
+		logger.Fatal("fatal")
+
+		assert(t, 1, got, true)
+	}
+}
+
+func TestHookCloseRemoveHook(t *testing.T) {
+	hook, err := New(Options{
+		Endpoint: "http://127.0.0.1/hooks/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isRegistered := func() (found bool) {
+		_hooksLocker.Lock()
+		defer _hooksLocker.Unlock()
+		for _, h := range _hooks {
+			if h == hook {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert(t, true, isRegistered(), true)
+	hook.Stop()
+	assert(t, false, isRegistered(), true)
+}
+
 func TestFire(t *testing.T) {
-	NewHook(_endpoint, _channel, _username, nil, logrus.TraceLevel)
+	hook, chanBody := newTestHook(t, Options{
+		MinLevel: logrus.TraceLevel,
+	})
 
 	tests := []struct {
 		desc string
@@ -57,7 +273,7 @@ func TestFire(t *testing.T) {
 				Level:   logrus.DebugLevel,
 				Message: "Test with empty data",
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":black_circle: msg=Test with empty data"}`,
 		},
 		{
 			desc: "With message",
@@ -68,7 +284,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":x: k1=v1 k2=v2 msg=Test"}`,
 		},
 		{
 			desc: "With level trace",
@@ -80,7 +296,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":mag_right: k1=v1 k2=v2 msg=Test trace"}`,
 		},
 		{
 			desc: "With level debug",
@@ -92,7 +308,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":black_circle: k1=v1 k2=v2 msg=Test debug"}`,
 		},
 		{
 			desc: "With level info",
@@ -104,7 +320,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":white_circle: k1=v1 k2=v2 msg=Test info"}`,
 		},
 		{
 			desc: "With level warning",
@@ -116,7 +332,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":interrobang: k1=v1 k2=v2 msg=Test warning"}`,
 		},
 		{
 			desc: "With level error",
@@ -128,7 +344,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":exclamation: k1=v1 k2=v2 msg=Test error"}`,
 		},
 		{
 			desc: "With level fatal",
@@ -140,7 +356,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":bangbang: k1=v1 k2=v2 msg=Test fatal"}`,
 		},
 		{
 			desc: "With level panic",
@@ -152,7 +368,7 @@ func TestFire(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":x: k1=v1 k2=v2 msg=Test panic"}`,
 		}, {
 			desc: "With complex fields",
 			in: logrus.Entry{
@@ -170,21 +386,19 @@ func TestFire(t *testing.T) {
 					"json": `{"test":"value"}`,
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","text":":white_circle: json={\"test\":\"value\"} string=string with space struct={s:a string n:10} msg={\"msg\":\"Test message \\\"JSON\\\"\"}"}`,
 		},
 	}
 
 	for _, test := range tests {
 		t.Log(test.desc)
 
-		err := _hook.Fire(&test.in)
+		err := hook.Fire(&test.in)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		res := <-_chanSent
-
-		assert(t, test.exp, res, true)
+		assert(t, test.exp, <-chanBody, true)
 	}
 }
 
@@ -193,7 +407,10 @@ func TestFireWithAttachment(t *testing.T) {
 		Pretext: "Send from test",
 	}
 
-	NewHook(_endpoint, _channel, _username, &attc, logrus.TraceLevel)
+	hook, chanBody := newTestHook(t, Options{
+		Attachment: &attc,
+		MinLevel:   logrus.TraceLevel,
+	})
 
 	tests := []struct {
 		desc string
@@ -206,7 +423,7 @@ func TestFireWithAttachment(t *testing.T) {
 				Level:   logrus.DebugLevel,
 				Message: "Test attachment with empty field",
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#000000","pretext":"Send from test","text":":black_circle: Test attachment with empty field"}]}`,
 		},
 		{
 			desc: "With message",
@@ -217,7 +434,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#FF0000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":x: Test with attachment"}]}`,
 		},
 		{
 			desc: "With level trace",
@@ -229,7 +446,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#000000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":mag_right: Test attachment trace"}]}`,
 		},
 		{
 			desc: "With level debug",
@@ -241,7 +458,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#000000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":black_circle: Test attachment debug"}]}`,
 		},
 		{
 			desc: "With level info",
@@ -253,7 +470,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#FFFFFF","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":white_circle: Test attachment info"}]}`,
 		},
 		{
 			desc: "With level warning",
@@ -265,7 +482,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#9F6000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":interrobang: Test attachment warning"}]}`,
 		},
 		{
 			desc: "With level error",
@@ -277,7 +494,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#990000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":exclamation: Test attachment error"}]}`,
 		},
 		{
 			desc: "With level fatal",
@@ -289,7 +506,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#CC0000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":bangbang: Test attachment fatal"}]}`,
 		},
 		{
 			desc: "With level panic",
@@ -301,7 +518,7 @@ func TestFireWithAttachment(t *testing.T) {
 					"k2": "v2",
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#FF0000","fields":[{"short":true,"title":"k1","value":"v1"},{"short":true,"title":"k2","value":"v2"}],"pretext":"Send from test","text":":x: Test attachment panic"}]}`,
 		}, {
 			desc: "With complex fields",
 			in: logrus.Entry{
@@ -319,21 +536,19 @@ func TestFireWithAttachment(t *testing.T) {
 					"json": `{"test":"value"}`,
 				},
 			},
-			exp: "ok",
+			exp: `{"username":"` + _hostname + `","attachments":[{"color":"#FFFFFF","fields":[{"short":true,"title":"json","value":"{\"test\":\"value\"}"},{"short":true,"title":"string","value":"string with space"},{"short":true,"title":"struct","value":"{s:a string n:10}"}],"pretext":"Send from test","text":":white_circle: {\"msg\":\"Test message \\\"with JSON\\\"\"}"}]}`,
 		},
 	}
 
 	for _, test := range tests {
 		t.Log(test.desc)
 
-		err := _hook.Fire(&test.in)
+		err := hook.Fire(&test.in)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		res := <-_chanSent
-
-		assert(t, test.exp, res, true)
+		assert(t, test.exp, <-chanBody, true)
 
 	}
 }
@@ -0,0 +1,87 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// Fingerprint return the identity of log entry, computed from its level,
+// message, and the value of fields in Options.Dedup.Fields.
+//
+// The same fingerprint is used to suppress repeated entries and to mute
+// the log entries, and it is set in the context of attachment actions.
+func (hook *Hook) Fingerprint(entry *logrus.Entry) string {
+	return hook.opts.Dedup.fingerprint(entry)
+}
+
+// Mute suppress the log entries with `fingerprint` for duration `d`.
+// Muting the same fingerprint again replace the previous duration.
+//
+// Hook implement the action.Muter, so it can be muted from Mattermost
+// using action.Mute.
+func (hook *Hook) Mute(fingerprint string, d time.Duration) {
+	hook.muteLocker.Lock()
+	hook.mutes[fingerprint] = time.Now().Add(d)
+	hook.muteLocker.Unlock()
+}
+
+// Unmute remove the mute on log entries with `fingerprint`.
+func (hook *Hook) Unmute(fingerprint string) {
+	hook.muteLocker.Lock()
+	delete(hook.mutes, fingerprint)
+	hook.muteLocker.Unlock()
+}
+
+// isMuted return true if the fingerprint `fp` is muted at time `now`.
+// The expired mute is removed.
+func (hook *Hook) isMuted(fp string, now time.Time) bool {
+	hook.muteLocker.Lock()
+	defer hook.muteLocker.Unlock()
+
+	until, ok := hook.mutes[fp]
+	if !ok {
+		return false
+	}
+	if now.Before(until) {
+		return true
+	}
+	delete(hook.mutes, fp)
+	return false
+}
+
+// setActionContext set the action ID, fingerprint, and message of log
+// entry into the integration context of each action in attachment, so the
+// action.Handler can dispatch and handle the action.
+// The default attachment in Options is not modified.
+func (msg *Message) setActionContext(entry *logrus.Entry, fp string) {
+	if msg.attc == nil || len(msg.attc.Actions) == 0 {
+		return
+	}
+
+	var actions = make([]Action, len(msg.attc.Actions))
+
+	for x, act := range msg.attc.Actions {
+		if act.Integration != nil {
+			integration := &Integration{
+				URL:     act.Integration.URL,
+				Context: make(map[string]interface{}),
+			}
+			for k, v := range act.Integration.Context {
+				integration.Context[k] = v
+			}
+			integration.Context[webhook.ContextActionID] = act.ID
+			integration.Context[webhook.ContextFingerprint] = fp
+			integration.Context[webhook.ContextMessage] = entry.Message
+			act.Integration = integration
+		}
+		actions[x] = act
+	}
+
+	msg.attc.Actions = actions
+}
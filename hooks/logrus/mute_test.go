@@ -0,0 +1,143 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/action"
+	"github.com/sirupsen/logrus"
+)
+
+// Hook can be muted by action.Mute.
+var _ action.Muter = (*Hook)(nil)
+
+func TestHookMute(t *testing.T) {
+	hook, chanBody := newTestHook(t, Options{
+		MinLevel: logrus.InfoLevel,
+	})
+
+	var (
+		muted = &logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Message: "connection refused",
+		}
+		other = &logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Message: "disk full",
+		}
+	)
+
+	hook.Mute(hook.Fingerprint(muted), time.Hour)
+
+	_ = hook.Fire(muted)
+	_ = hook.Fire(other)
+
+	_, err := hook.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		`{"username":"` + _hostname + `","text":":exclamation: msg=disk full"}`,
+	}
+	assert(t, exp, drainBody(chanBody), true)
+
+	hook.Unmute(hook.Fingerprint(muted))
+
+	_ = hook.Fire(muted)
+
+	_, err = hook.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp = []string{
+		`{"username":"` + _hostname + `","text":":exclamation: msg=connection refused"}`,
+	}
+	assert(t, exp, drainBody(chanBody), true)
+}
+
+func TestHookIsMuted(t *testing.T) {
+	var (
+		hook = &Hook{
+			mutes: make(map[string]time.Time),
+		}
+		now = time.Now()
+	)
+
+	hook.Mute("fp", time.Minute)
+
+	assert(t, true, hook.isMuted("fp", now), true)
+	assert(t, false, hook.isMuted("other", now), true)
+	assert(t, false, hook.isMuted("fp", now.Add(time.Hour)), true)
+
+	// The expired mute should be removed.
+	assert(t, 0, len(hook.mutes), true)
+}
+
+func TestHookActionContext(t *testing.T) {
+	var (
+		defAttc = &Attachment{
+			Actions: []Action{{
+				ID:   "ack",
+				Name: "Acknowledge",
+				Integration: &Integration{
+					URL:     "https://example.com/action",
+					Context: map[string]interface{}{"team": "ops"},
+				},
+			}, {
+				ID:   "nointegration",
+				Name: "No integration",
+			}},
+		}
+		entry = &logrus.Entry{
+			Level:   logrus.ErrorLevel,
+			Message: "disk full",
+		}
+		got struct {
+			Attachments []struct {
+				Actions []struct {
+					Integration *struct {
+						Context map[string]string
+					}
+				}
+			}
+		}
+	)
+
+	hook, chanBody := newTestHook(t, Options{
+		Attachment: defAttc,
+		MinLevel:   logrus.InfoLevel,
+	})
+
+	err := hook.Fire(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = json.Unmarshal([]byte(<-chanBody), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expContext := map[string]string{
+		"action_id":   "ack",
+		"fingerprint": hook.Fingerprint(entry),
+		"message":     "disk full",
+		"team":        "ops",
+	}
+
+	actions := got.Attachments[0].Actions
+	assert(t, 2, len(actions), true)
+	assert(t, expContext, actions[0].Integration.Context, true)
+	assert(t, true, actions[1].Integration == nil, true)
+
+	// The default attachment should not be modified.
+	assert(t, 1, len(defAttc.Actions[0].Integration.Context), true)
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrEndpointEmpty define an error when creating Hook with empty Endpoint.
+// It is the same error as webhook.ErrEndpointEmpty.
+var ErrEndpointEmpty = webhook.ErrEndpointEmpty
+
+// Options define the configuration for creating new Hook.
+type Options struct {
+	// Attachment if not nil, each log will be send as attachment.
+	// The value will act as default attachment value, and it will
+	// replace the `Text` with `Entry.Message` and `Fields` with
+	// `Entry.Data`.
+	Attachment *Attachment
+
+	// Caller define the options to render the caller information, when
+	// the logger set with ReportCaller.
+	Caller CallerOptions
+
+	// CodeMaxLines define the maximum number of lines of the error
+	// and multi-line field value, that rendered as code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+
+	// Formatter define the formatter to convert the log entry into
+	// the content of post.
+	// If its nil, the AttachmentFormatter is used if Attachment is not
+	// nil, otherwise the TextFormatter, both with the Caller and
+	// CodeMaxLines options.
+	Formatter Formatter
+
+	// Template define the text/template to render the log entry, see
+	// TemplateFormatter for list of functions.
+	// It is ignored if the Formatter is set.
+	Template string
+
+	// TemplateFile define the file that contains the text/template to
+	// render the log entry.
+	// It is ignored if the Formatter or Template is set.
+	TemplateFile string
+
+	// HTTPClient define the HTTP client that will be used to send the
+	// message to Mattermost.
+	// If its nil, each Hook will create their own HTTP client.
+	HTTPClient *http.Client
+
+	// Endpoint define the URL of Mattermost incoming webhook.
+	// This field is required.
+	Endpoint string
+
+	// Channel define the channel name where the log will be send.
+	// If its empty then it will use the default channel defined in
+	// incoming webhook setting.
+	Channel string
+
+	// Username define the user name that send the log.
+	// If its empty then it will use the hostname.
+	Username string
+
+	// RateLimit define the maximum number of requests per second to
+	// Mattermost.
+	// Default to 10, follow the default Mattermost rate limit.
+	// Set to negative value to disable it.
+	RateLimit float64
+
+	// RateBurst define the maximum number of requests that can be send
+	// at once, before limited by RateLimit.
+	// Default to 100.
+	RateBurst int
+
+	// Workers define the maximum number of concurrent requests to
+	// Mattermost.
+	// Default to 3.
+	// If the spool is enabled, the messages are always send by single
+	// worker to keep the order.
+	Workers int
+
+	// QueueSize define the maximum number of messages that can be
+	// queued in memory before send.
+	// Default to 30.
+	QueueSize int
+
+	// Overflow define the policy when the queue is full.
+	// Default to OverflowBlock.
+	Overflow OverflowPolicy
+
+	// OverflowLevel define the level for OverflowDropBelow policy.
+	// When the queue is full, the log with level less severe than
+	// OverflowLevel will be dropped.
+	OverflowLevel logrus.Level
+
+	// DropReportInterval define the interval to send the number of
+	// dropped messages to Mattermost, if any.
+	// Default to 1 minute.
+	// Set to negative value to disable it.
+	DropReportInterval time.Duration
+
+	// FlushTimeout define the maximum duration to wait for queued
+	// messages to be send, when firing the log with level panic or
+	// fatal, before logrus panic or exit the program.
+	// Default to 5 seconds.
+	FlushTimeout time.Duration
+
+	// Dedup define the options to suppress repeated log entries.
+	// The default is all log entries are send.
+	Dedup DedupOptions
+
+	// Batch define the options to send multiple log entries as single
+	// message.
+	// The default is each log entry is send as one message.
+	Batch BatchOptions
+
+	// Oversize define the options to truncate or split the message
+	// that is larger than the maximum post size.
+	// The default is the text is truncated at 16383 characters.
+	Oversize OversizeOptions
+
+	// Spool define the options for storing the queued messages in
+	// files.
+	// The default is the messages are queued in memory.
+	Spool SpoolOptions
+
+	// Routes define list of rules to send the log entry to specific
+	// destination, evaluated in order.
+	// If no route match, the log entry is send to Endpoint, Channel,
+	// and Username in Options.
+	Routes []Route
+
+	// Retry define the policy to retry sending message when the
+	// request to Mattermost failed temporarily.
+	// The default is no retry.
+	Retry RetryPolicy
+
+	// OnError define the function that will be called when the message
+	// failed to be send to Mattermost and is discarded, after all
+	// attempts in Retry.
+	// The error is *DeliveryError.
+	// If the spool is enabled, it is called only if the message is
+	// failed permanently, since the message that failed temporarily is
+	// kept in spool.
+	//
+	// The function is called by the consumer routine, so it should not
+	// block and should be safe to be called concurrently.
+	// To prevent loop, it should not log the error using the logger
+	// that has this hook.
+	OnError func(msg *Message, err error)
+
+	// MinLevel define the minimum level of log that will be send to
+	// Mattermost.
+	// Since the zero value is logrus.PanicLevel, the default is only
+	// log with level panic will be send.
+	MinLevel logrus.Level
+}
+
+// validate check the required fields in Options.
+func (opts *Options) validate() (err error) {
+	if len(opts.Endpoint) == 0 {
+		return ErrEndpointEmpty
+	}
+	return nil
+}
+
+// initTemplate create the TemplateFormatter from Template or
+// TemplateFile, if the Formatter is not set.
+func (opts *Options) initTemplate() (err error) {
+	if opts.Formatter != nil {
+		return nil
+	}
+
+	var f *TemplateFormatter
+
+	if len(opts.Template) > 0 {
+		f, err = NewTemplateFormatter(opts.Template)
+	} else if len(opts.TemplateFile) > 0 {
+		f, err = NewTemplateFormatterFile(opts.TemplateFile)
+	}
+	if err != nil || f == nil {
+		return err
+	}
+
+	f.Caller = opts.Caller
+	f.CodeMaxLines = opts.CodeMaxLines
+	opts.Formatter = f
+
+	return nil
+}
+
+// setDefault set the default value for optional fields.
+//
+// The default value of delivery options, like RateLimit and Retry, are set
+// by delivery.New.
+func (opts *Options) setDefault() {
+	if opts.CodeMaxLines == 0 {
+		opts.CodeMaxLines = record.DefCodeMaxLines
+	}
+	if opts.FlushTimeout <= 0 {
+		opts.FlushTimeout = defFlushTimeout
+	}
+	opts.Batch.init()
+	opts.Caller.init()
+	if opts.Formatter == nil {
+		opts.Formatter = opts.defFormatter()
+	}
+}
+
+// defFormatter return the default Formatter based on the Attachment.
+func (opts *Options) defFormatter() Formatter {
+	if opts.Attachment != nil {
+		return &AttachmentFormatter{
+			Attachment:   opts.Attachment,
+			Caller:       opts.Caller,
+			CodeMaxLines: opts.CodeMaxLines,
+		}
+	}
+	return &TextFormatter{
+		Caller:       opts.Caller,
+		CodeMaxLines: opts.CodeMaxLines,
+	}
+}
+
+// levels return list of logrus level from PanicLevel until MinLevel.
+func (opts *Options) levels() (levels []logrus.Level) {
+	levels = make([]logrus.Level, 0, len(logrus.AllLevels))
+	for _, lvl := range logrus.AllLevels { // sorted reversally
+		if lvl <= opts.MinLevel {
+			levels = append(levels, lvl)
+		}
+	}
+	return levels
+}
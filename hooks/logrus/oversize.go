@@ -0,0 +1,101 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+type (
+	// OversizePolicy define what the hook will do when the message is
+	// larger than the maximum size, see webhook.OversizePolicy.
+	OversizePolicy = webhook.OversizePolicy
+
+	// OversizeOptions define the options to handle the message that is
+	// larger than the maximum post size in Mattermost, see
+	// webhook.OversizeOptions.
+	// The text of attachments in batch message is always truncated.
+	OversizeOptions = webhook.OversizeOptions
+)
+
+// List of oversize policy, see webhook.OversizePolicy.
+const (
+	OversizeTruncate = webhook.OversizeTruncate
+	OversizeSplit    = webhook.OversizeSplit
+)
+
+// fitSize return list of messages where each of them is not larger than
+// the maximum size in `opts`.
+func (msg *Message) fitSize(opts *OversizeOptions) (msgs []*Message) {
+	maxSize := opts.Size()
+	if maxSize == 0 {
+		return []*Message{msg}
+	}
+
+	list := msg.attachments()
+	if len(list) == 0 {
+		parts := opts.Fit(msg.plainText())
+		if len(parts) == 1 {
+			// Keep the rendered text, so its not rendered twice
+			// on MarshalJSON.
+			msg.text = parts[0]
+			return []*Message{msg}
+		}
+		for _, part := range parts {
+			sub := msg.newPart()
+			sub.text = part
+			msgs = append(msgs, sub)
+		}
+		return msgs
+	}
+
+	for _, attc := range list {
+		for x, field := range attc.Fields {
+			attc.Fields[x].Value = webhook.TruncateText(field.Value,
+				maxSize)
+		}
+	}
+	if len(list) > 1 {
+		for _, attc := range list {
+			attc.Text = webhook.TruncateText(attc.Text, maxSize)
+		}
+		return []*Message{msg}
+	}
+
+	var (
+		attc  = list[0]
+		parts = opts.Fit(attc.Text)
+	)
+
+	attc.Text = parts[0]
+	msgs = append(msgs, msg)
+
+	for _, part := range parts[1:] {
+		sub := msg.newPart()
+		sub.attc = &Attachment{
+			Timestamp: attc.Timestamp,
+			Color:     attc.Color,
+			Fallback:  attc.Fallback,
+			Title:     attc.Title,
+			TitleLink: attc.TitleLink,
+			Text:      part,
+		}
+		msgs = append(msgs, sub)
+	}
+	return msgs
+}
+
+// newPart create new Message with the same destination and level as
+// `msg`, without text and attachment.
+func (msg *Message) newPart() *Message {
+	return &Message{
+		endpoint:   msg.endpoint,
+		channel:    msg.channel,
+		username:   msg.username,
+		hostname:   msg.hostname,
+		iconURL:    msg.iconURL,
+		entryLevel: msg.entryLevel,
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHookOversize(t *testing.T) {
+	var (
+		line  = strings.Repeat("x", 28)
+		text  = line + "\n" + line
+		tests = []struct {
+			desc string
+			attc *Attachment
+			exp  []string
+			opts OversizeOptions
+		}{{
+			desc: "With text truncated",
+			opts: OversizeOptions{MaxSize: 64},
+			exp: []string{
+				`{"username":"` + _hostname + `","text":":white_circle: msg=` + line + `\n` + line[:15] + `…"}`,
+			},
+		}, {
+			desc: "With text split",
+			opts: OversizeOptions{
+				MaxSize: 64,
+				Policy:  OversizeSplit,
+			},
+			exp: []string{
+				`{"username":"` + _hostname + `","text":"(1/2) :white_circle: msg=` + line + `"}`,
+				`{"username":"` + _hostname + `","text":"(2/2) ` + line + `"}`,
+			},
+		}, {
+			desc: "With attachment split",
+			opts: OversizeOptions{
+				MaxSize: 64,
+				Policy:  OversizeSplit,
+			},
+			attc: &Attachment{
+				Title: "title",
+			},
+			exp: []string{
+				`{"username":"` + _hostname + `","attachments":[{"color":"#FFFFFF","text":"(1/2) :white_circle: ` + line + `","title":"title"}]}`,
+				`{"username":"` + _hostname + `","attachments":[{"color":"#FFFFFF","text":"(2/2) ` + line + `","title":"title"}]}`,
+			},
+		}}
+	)
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		hook, chanBody := newTestHook(t, Options{
+			Attachment: test.attc,
+			Oversize:   test.opts,
+			Workers:    1,
+			MinLevel:   logrus.InfoLevel,
+		})
+
+		err := hook.Fire(&logrus.Entry{
+			Level:   logrus.InfoLevel,
+			Message: text,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]string, 0, len(test.exp))
+		for range test.exp {
+			got = append(got, <-chanBody)
+		}
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func FuzzMessageFitSize(f *testing.F) {
+	f.Add("line 1\nline 2 with space", 64, false)
+	f.Add(strings.Repeat("日本語\n", 100), 70, true)
+	f.Add(strings.Repeat("\xff\"\\", 100), 64, false)
+
+	f.Fuzz(func(t *testing.T, text string, max int, attc bool) {
+		var (
+			opts = OversizeOptions{
+				MaxSize: max,
+				Policy:  OversizeSplit,
+			}
+			msg = &Message{
+				entryLevel: logrus.InfoLevel,
+				entryMsg:   text,
+			}
+		)
+
+		if attc {
+			msg.attc = NewAttachment(&Attachment{},
+				&logrus.Entry{Message: text})
+		}
+
+		for _, part := range msg.fitSize(&opts) {
+			out, err := part.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !utf8.Valid(out) {
+				t.Fatalf("invalid UTF-8: %q", out)
+			}
+
+			var got struct {
+				Text        string
+				Attachments []struct {
+					Text string
+				}
+			}
+
+			err = json.Unmarshal(out, &got)
+			if err != nil {
+				t.Fatalf("%q: %s", out, err)
+			}
+			if attc {
+				got.Text = got.Attachments[0].Text
+			}
+			if opts.Size() > 0 &&
+				utf8.RuneCountInString(got.Text) > opts.Size() {
+				t.Fatalf("text larger than %d: %q", opts.Size(),
+					got.Text)
+			}
+		}
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package prometheus contains the prometheus.Collector for delivery
+// metrics of Hook in hooks/logrus, and of the hooks for slog, zap, and
+// zerolog.
+//
+// The metrics are read from delivery.MetricsSnapshot on each scrape, so
+// the Collector can be registered before or after the hooks are created.
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+)
+
+// namespace define the prefix of all metric names.
+const namespace = "mattermost_hook"
+
+// _labels define the variable labels of all metrics.
+var _labels = []string{"level", "endpoint", "channel"}
+
+// Collector implement the prometheus.Collector that export the delivery
+// metrics of all hooks.
+//
+// The following metrics are exported, each labelled by "level",
+// "endpoint", and "channel",
+//
+//   - mattermost_hook_messages_queued_total
+//   - mattermost_hook_messages_sent_total
+//   - mattermost_hook_messages_retried_total
+//   - mattermost_hook_messages_dropped_total
+//   - mattermost_hook_messages_failed_total
+//   - mattermost_hook_send_duration_seconds
+type Collector struct {
+	queued  *prom.Desc
+	sent    *prom.Desc
+	retried *prom.Desc
+	dropped *prom.Desc
+	failed  *prom.Desc
+	latency *prom.Desc
+}
+
+// NewCollector create new Collector.
+// The Collector should be registered only once, for example,
+//
+//	prometheus.MustRegister(mmprom.NewCollector())
+func NewCollector() (c *Collector) {
+	c = &Collector{
+		queued: newCounterDesc("queued",
+			"Number of messages queued to be send to Mattermost."),
+		sent: newCounterDesc("sent",
+			"Number of messages send to Mattermost successfully."),
+		retried: newCounterDesc("retried",
+			"Number of retries to send messages to Mattermost."),
+		dropped: newCounterDesc("dropped",
+			"Number of messages dropped because the queue or spool is full, or expired."),
+		failed: newCounterDesc("failed",
+			"Number of messages failed to be send to Mattermost and discarded."),
+		latency: prom.NewDesc(
+			prom.BuildFQName(namespace, "", "send_duration_seconds"),
+			"Duration of each request attempt to Mattermost; a retried message is observed once per attempt.",
+			_labels, nil),
+	}
+	return c
+}
+
+// newCounterDesc create the description of counter
+// mattermost_hook_messages_<name>_total.
+func newCounterDesc(name, help string) *prom.Desc {
+	return prom.NewDesc(
+		prom.BuildFQName(namespace, "messages", name+"_total"),
+		help, _labels, nil)
+}
+
+// Describe send the description of all metrics to `ch`.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.queued
+	ch <- c.sent
+	ch <- c.retried
+	ch <- c.dropped
+	ch <- c.failed
+	ch <- c.latency
+}
+
+// Collect send the current metrics of all hooks to `ch`.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	for _, m := range delivery.MetricsSnapshot() {
+		labels := []string{m.Level, m.Endpoint, m.Channel}
+
+		ch <- prom.MustNewConstMetric(c.queued, prom.CounterValue,
+			float64(m.Queued), labels...)
+		ch <- prom.MustNewConstMetric(c.sent, prom.CounterValue,
+			float64(m.Sent), labels...)
+		ch <- prom.MustNewConstMetric(c.retried, prom.CounterValue,
+			float64(m.Retried), labels...)
+		ch <- prom.MustNewConstMetric(c.dropped, prom.CounterValue,
+			float64(m.Dropped), labels...)
+		ch <- prom.MustNewConstMetric(c.failed, prom.CounterValue,
+			float64(m.Failed), labels...)
+
+		buckets := make(map[float64]uint64, len(m.Latency.Buckets))
+		for x, upper := range m.Latency.Buckets {
+			buckets[upper] = m.Latency.Counts[x]
+		}
+		ch <- prom.MustNewConstHistogram(c.latency, m.Latency.Count,
+			m.Latency.Sum, buckets, labels...)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	mmlogrus "github.com/shuLhan/mattermost-integration/hooks/logrus"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			_, _ = res.Write([]byte("ok"))
+		}))
+	t.Cleanup(srv.Close)
+
+	hook, err := mmlogrus.New(mmlogrus.Options{
+		Endpoint: srv.URL + "/hooks/token",
+		Channel:  "ops",
+		MinLevel: logrus.ErrorLevel,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(hook.Stop)
+
+	err = hook.Fire(&logrus.Entry{
+		Level:   logrus.ErrorLevel,
+		Message: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = hook.Flush(context.Background())
+
+	reg := prom.NewPedanticRegistry()
+	err = reg.Register(NewCollector())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		expLabels = map[string]string{
+			"level":    "error",
+			"endpoint": srv.URL + "/hooks/xxxxx",
+			"channel":  "ops",
+		}
+		got = make(map[string]float64)
+	)
+
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if !reflect.DeepEqual(expLabels, labels) {
+				continue
+			}
+			if m.GetHistogram() != nil {
+				got[mf.GetName()] = float64(m.GetHistogram().GetSampleCount())
+				continue
+			}
+			got[mf.GetName()] = m.GetCounter().GetValue()
+		}
+	}
+
+	exp := map[string]float64{
+		"mattermost_hook_messages_queued_total":  1,
+		"mattermost_hook_messages_sent_total":    1,
+		"mattermost_hook_messages_retried_total": 0,
+		"mattermost_hook_messages_dropped_total": 0,
+		"mattermost_hook_messages_failed_total":  0,
+		"mattermost_hook_send_duration_seconds":  1,
+	}
+	assert(t, exp, got, true)
+}
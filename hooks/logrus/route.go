@@ -0,0 +1,189 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Route define a rule to send the log entry to specific destination.
+//
+// The log entry match the route if its level is in Levels, all of the
+// Fields match, and the Match function, if not nil, return true.
+//
+// The Endpoint, Channel, Username, and IconURL define the destination of
+// log entry that match the route.
+// If the Endpoint, Channel, or Username is empty, it will use the value
+// from Options.
+type Route struct {
+	// Match define custom function to match the log entry.
+	Match func(entry *logrus.Entry) bool
+
+	// Fields define list of field key and value that must be exist in
+	// logrus.Entry.Data.
+	// The field value is compared as string, formatted using "%v".
+	Fields map[string]string
+
+	// Endpoint define the URL of Mattermost incoming webhook.
+	Endpoint string
+
+	// Channel define the channel name where the log will be send.
+	Channel string
+
+	// Username define the user name that send the log.
+	Username string
+
+	// IconURL define the URL of image used as profile picture of the
+	// log sender.
+	IconURL string
+
+	// Levels define list of log level that match the route.
+	// If its empty, all levels are matched.
+	// See LevelRange to create list of level from range.
+	Levels []logrus.Level
+
+	// Continue define whether the next routes should be evaluated after
+	// the log entry match this route.
+	// This allow single log entry to be send to multiple destinations.
+	Continue bool
+}
+
+// LevelRange return list of logrus level from level `from` until `to`,
+// inclusive.
+// For example, LevelRange(logrus.PanicLevel, logrus.ErrorLevel) return
+// panic, fatal, and error level.
+func LevelRange(from, to logrus.Level) (levels []logrus.Level) {
+	if from > to {
+		from, to = to, from
+	}
+	for lvl := from; lvl <= to; lvl++ {
+		levels = append(levels, lvl)
+	}
+	return levels
+}
+
+// isMatch return true if the log entry match the route.
+func (route *Route) isMatch(entry *logrus.Entry) bool {
+	if len(route.Levels) > 0 {
+		var found bool
+		for _, lvl := range route.Levels {
+			if lvl == entry.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, exp := range route.Fields {
+		v, ok := entry.Data[k]
+		if !ok || fmt.Sprintf("%v", v) != exp {
+			return false
+		}
+	}
+	if route.Match != nil && !route.Match(entry) {
+		return false
+	}
+	return true
+}
+
+// route create the message for each destination that match the log
+// entry and push it into batch or queue.
+// If no route match, the message is send to the default destination in
+// Options.
+// The fingerprint `fp` is set into the context of attachment actions.
+func (hook *Hook) route(entry *logrus.Entry, fp string) (err error) {
+	var (
+		msg     *Message
+		route   *Route
+		errSend error
+		matched bool
+	)
+
+	for x := range hook.opts.Routes {
+		route = &hook.opts.Routes[x]
+		if !route.isMatch(entry) {
+			continue
+		}
+		matched = true
+
+		msg, errSend = hook.newRouteMessage(route, entry)
+		if errSend == nil {
+			msg.setActionContext(entry, fp)
+			errSend = hook.submit(msg)
+		}
+		if errSend != nil {
+			err = errSend
+		}
+		if !route.Continue {
+			break
+		}
+	}
+	if matched {
+		return err
+	}
+
+	msg, err = hook.newMessage(hook.Channel(), hook.Username(), entry)
+	if err != nil {
+		return err
+	}
+	msg.setActionContext(entry, fp)
+
+	return hook.submit(msg)
+}
+
+// newMessage create new Message for the log entry using the hook
+// Formatter.
+func (hook *Hook) newMessage(channel, username string, entry *logrus.Entry) (
+	msg *Message, err error,
+) {
+	var logp = "newMessage"
+
+	msg, entry = newMessageEntry(channel, username, hook.Hostname(), entry)
+
+	msg.text, msg.attc, err = hook.opts.Formatter.Format(entry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	return msg, nil
+}
+
+// newRouteMessage create new message for the log entry with destination
+// from route.
+// The empty Channel and Username in route is replaced with the value from
+// Options, while the empty Endpoint means the message is send to
+// Options.Endpoint.
+// The reserved fields in log entry take precedence over the route.
+func (hook *Hook) newRouteMessage(route *Route, entry *logrus.Entry) (
+	msg *Message, err error,
+) {
+	var (
+		channel  = route.Channel
+		username = route.Username
+	)
+
+	if len(channel) == 0 {
+		channel = hook.opts.Channel
+	}
+	if len(username) == 0 {
+		username = hook.opts.Username
+	}
+
+	msg, err = hook.newMessage(channel, username, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.endpoint = route.Endpoint
+	if len(msg.iconURL) == 0 {
+		msg.iconURL = route.IconURL
+	}
+
+	return msg, nil
+}
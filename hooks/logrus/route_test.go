@@ -0,0 +1,227 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelRange(t *testing.T) {
+	tests := []struct {
+		desc string
+		exp  []logrus.Level
+		from logrus.Level
+		to   logrus.Level
+	}{
+		{
+			desc: "From panic to error",
+			from: logrus.PanicLevel,
+			to:   logrus.ErrorLevel,
+			exp: []logrus.Level{
+				logrus.PanicLevel,
+				logrus.FatalLevel,
+				logrus.ErrorLevel,
+			},
+		},
+		{
+			desc: "With reversed range",
+			from: logrus.InfoLevel,
+			to:   logrus.WarnLevel,
+			exp: []logrus.Level{
+				logrus.WarnLevel,
+				logrus.InfoLevel,
+			},
+		},
+		{
+			desc: "With single level",
+			from: logrus.DebugLevel,
+			to:   logrus.DebugLevel,
+			exp:  []logrus.Level{logrus.DebugLevel},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := LevelRange(test.from, test.to)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestRouteIsMatch(t *testing.T) {
+	route := Route{
+		Levels: LevelRange(logrus.PanicLevel, logrus.WarnLevel),
+		Fields: map[string]string{
+			"component": "billing",
+		},
+	}
+
+	tests := []struct {
+		desc  string
+		entry *logrus.Entry
+		exp   bool
+	}{
+		{
+			desc: "With level and field match",
+			entry: &logrus.Entry{
+				Level: logrus.ErrorLevel,
+				Data:  logrus.Fields{"component": "billing"},
+			},
+			exp: true,
+		},
+		{
+			desc: "With level not match",
+			entry: &logrus.Entry{
+				Level: logrus.InfoLevel,
+				Data:  logrus.Fields{"component": "billing"},
+			},
+		},
+		{
+			desc: "With field value not match",
+			entry: &logrus.Entry{
+				Level: logrus.ErrorLevel,
+				Data:  logrus.Fields{"component": "auth"},
+			},
+		},
+		{
+			desc: "With field not exist",
+			entry: &logrus.Entry{
+				Level: logrus.ErrorLevel,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := route.isMatch(test.entry)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestHookRoute(t *testing.T) {
+	var (
+		srvAlerts, chanAlerts = newTestServer()
+		srvBilling, chanBill  = newTestServer()
+	)
+
+	t.Cleanup(srvAlerts.Close)
+	t.Cleanup(srvBilling.Close)
+
+	hook, chanDefault := newTestHook(t, Options{
+		MinLevel: logrus.InfoLevel,
+		Routes: []Route{{
+			Fields:   map[string]string{"component": "billing"},
+			Endpoint: srvBilling.URL,
+			Channel:  "billing-logs",
+			Continue: true,
+		}, {
+			Levels:   LevelRange(logrus.PanicLevel, logrus.ErrorLevel),
+			Endpoint: srvAlerts.URL,
+			Channel:  "alerts",
+			Username: "alert-bot",
+			IconURL:  "https://example.com/alert.png",
+		}, {
+			Levels:  []logrus.Level{logrus.WarnLevel},
+			Channel: "ops",
+		}},
+	})
+
+	tests := []struct {
+		entry      *logrus.Entry
+		desc       string
+		expDefault []string
+		expAlerts  []string
+		expBilling []string
+	}{
+		{
+			desc: "With error level",
+			entry: &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Message: "disk full",
+			},
+			expAlerts: []string{
+				`{"channel":"alerts","username":"alert-bot","icon_url":"https://example.com/alert.png","text":":exclamation: msg=disk full"}`,
+			},
+		},
+		{
+			desc: "With warning level on default endpoint",
+			entry: &logrus.Entry{
+				Level:   logrus.WarnLevel,
+				Message: "disk almost full",
+			},
+			expDefault: []string{
+				`{"channel":"ops","username":"` + hook.Hostname() + `","text":":interrobang: msg=disk almost full"}`,
+			},
+		},
+		{
+			desc: "With billing component, fan out to alerts",
+			entry: &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Message: "payment failed",
+				Data:    logrus.Fields{"component": "billing"},
+			},
+			expBilling: []string{
+				`{"channel":"billing-logs","username":"` + hook.Hostname() + `","text":":exclamation: component=billing msg=payment failed"}`,
+			},
+			expAlerts: []string{
+				`{"channel":"alerts","username":"alert-bot","icon_url":"https://example.com/alert.png","text":":exclamation: component=billing msg=payment failed"}`,
+			},
+		},
+		{
+			desc: "With channel override in log entry",
+			entry: &logrus.Entry{
+				Level:   logrus.ErrorLevel,
+				Message: "disk full",
+				Data:    logrus.Fields{FieldChannel: "disk"},
+			},
+			expAlerts: []string{
+				`{"channel":"disk","username":"alert-bot","icon_url":"https://example.com/alert.png","text":":exclamation: msg=disk full"}`,
+			},
+		},
+		{
+			desc: "With no route match",
+			entry: &logrus.Entry{
+				Level:   logrus.InfoLevel,
+				Message: "started",
+			},
+			expDefault: []string{
+				`{"username":"` + _hostname + `","text":":white_circle: msg=started"}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		err := hook.Fire(test.entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = hook.Flush(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.expDefault, drainBody(chanDefault), true)
+		assert(t, test.expAlerts, drainBody(chanAlerts), true)
+		assert(t, test.expBilling, drainBody(chanBill), true)
+	}
+}
+
+// drainBody return all of the request body that has been received by
+// test server.
+func drainBody(chanBody chan string) (bodies []string) {
+	for len(chanBody) > 0 {
+		bodies = append(bodies, <-chanBody)
+	}
+	return bodies
+}
@@ -0,0 +1,217 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"bytes"
+	_ "embed" // For embedding the default templates.
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// List of default templates for TemplateFormatter.
+var (
+	// TemplateDefault render the log entry like TextFormatter.
+	//
+	//go:embed templates/default.tmpl
+	TemplateDefault string
+
+	// TemplateDetail render the message as header, followed by the
+	// time, the caller, and Markdown table of fields.
+	//
+	//go:embed templates/detail.tmpl
+	TemplateDetail string
+)
+
+// _markdownEscaper escape the characters that has special meaning in
+// Markdown.
+var _markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`#`, `\#`,
+	`|`, `\|`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`~`, `\~`,
+)
+
+// TemplateFormatter render the log entry as text using text/template.
+//
+// The template is executed with the *logrus.Entry as data, so the
+// template can access the entry fields, for example {{.Message}},
+// {{.Level}}, {{.Time}}, {{.Data}}, and {{.Caller}}.
+// When created by New from Template or TemplateFile, the Caller and
+// CodeMaxLines are set from Options.
+//
+// In addition to the text/template built-in functions, the following
+// functions are available,
+//
+//	icon LEVEL          - the emoji of log level, for example ":exclamation:".
+//	color LEVEL         - the color of log level, for example "#990000".
+//	keys DATA           - the sorted keys of entry Data.
+//	field ENTRY KEY     - the entry Data value of KEY formatted with "%+v",
+//	                      or empty string if not exist.
+//	isCode KEY VALUE    - true if the field should be rendered as code
+//	                      block, that is the error or multi-line value.
+//	code VALUE          - wrap the VALUE in code block, at most
+//	                      CodeMaxLines lines.
+//	caller ENTRY        - the caller of entry as "file:line", or as
+//	                      Markdown link if Caller.URL is set, or empty
+//	                      string if the entry does not have caller.
+//	truncate MAX VALUE  - cut the VALUE at MAX characters with ellipsis.
+//	time LAYOUT TIME    - format the TIME using the LAYOUT.
+//	escape VALUE        - escape the Markdown characters in VALUE.
+//
+// For example,
+//
+//	{{icon .Level}} **{{escape .Message}}** {{field . "user" | truncate 20}}
+//
+// The trailing new lines in output are removed.
+type TemplateFormatter struct {
+	tmpl *template.Template
+
+	// Caller define the options to render the caller information.
+	Caller CallerOptions
+
+	// CodeMaxLines define the maximum number of lines in code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+}
+
+// NewTemplateFormatter create new TemplateFormatter from template
+// `text`.
+//
+// The template is validated by executing it with a sample log entry, so
+// the error in template is reported before the hook is created.
+func NewTemplateFormatter(text string) (f *TemplateFormatter, err error) {
+	var logp = "NewTemplateFormatter"
+
+	f = &TemplateFormatter{}
+
+	f.tmpl, err = template.New("mattermost").Funcs(f.funcs()).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	_, _, err = f.Format(sampleEntry())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	return f, nil
+}
+
+// NewTemplateFormatterFile create new TemplateFormatter from template
+// file.
+func NewTemplateFormatterFile(path string) (f *TemplateFormatter, err error) {
+	var logp = "NewTemplateFormatterFile"
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	f, err = NewTemplateFormatter(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", logp, path, err)
+	}
+
+	return f, nil
+}
+
+// Format the log entry using the template.
+func (f *TemplateFormatter) Format(entry *logrus.Entry) (
+	text string, attc *Attachment, err error,
+) {
+	var buf bytes.Buffer
+
+	err = f.tmpl.Execute(&buf, entry)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil, nil
+}
+
+// funcs return the functions for template.
+func (f *TemplateFormatter) funcs() template.FuncMap {
+	return template.FuncMap{
+		"icon": func(level logrus.Level) string {
+			return levelTable(_iconsLevel, level)
+		},
+		"color": func(level logrus.Level) string {
+			return levelTable(_colorsLevel, level)
+		},
+		"keys": sortedKeys,
+		"field": func(entry *logrus.Entry, key string) string {
+			v, ok := entry.Data[key]
+			if !ok {
+				return ""
+			}
+			return record.FieldValue(v)
+		},
+		"isCode": record.IsCodeField,
+		"code": func(value string) string {
+			return record.CodeBlock(value, codeMaxLines(f.CodeMaxLines))
+		},
+		"caller": func(entry *logrus.Entry) string {
+			_, link := f.Caller.format(entry)
+			return link
+		},
+		"truncate": func(max int, value string) string {
+			return webhook.TruncateText(value, max)
+		},
+		"time": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"escape": _markdownEscaper.Replace,
+	}
+}
+
+// levelTable return the value of `level` in `table`, or empty string if
+// the level is out of range.
+func levelTable(table []string, level logrus.Level) string {
+	if int(level) >= len(table) {
+		return ""
+	}
+	return table[level]
+}
+
+// sampleEntry return the log entry for validating the template.
+func sampleEntry() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetReportCaller(true)
+
+	return &logrus.Entry{
+		Logger:  logger,
+		Level:   logrus.ErrorLevel,
+		Time:    time.Date(2023, 2, 18, 10, 0, 0, 0, time.UTC),
+		Message: "sample message",
+		Data: logrus.Fields{
+			logrus.ErrorKey: errors.New("sample error"),
+			"key":           "value",
+		},
+		Caller: &runtime.Frame{
+			File:     "main.go",
+			Line:     1,
+			Function: "main.main",
+		},
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logrus
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/sirupsen/logrus"
+)
+
+// _updateGolden if true, the golden files in testdata is replaced with the
+// output of test.
+var _updateGolden = flag.Bool("update", false, "update the golden files")
+
+// newTemplateEntry return the log entry for testing the templates.
+func newTemplateEntry() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetReportCaller(true)
+
+	return &logrus.Entry{
+		Logger:  logger,
+		Level:   logrus.WarnLevel,
+		Time:    time.Date(2023, 2, 18, 10, 4, 58, 0, time.UTC),
+		Message: "payment *failed* for [order]",
+		Data: logrus.Fields{
+			logrus.ErrorKey: "card declined\nmain.charge\n\t/src/pay.go:42",
+			"order_id":      1234,
+			"user":          "a|b",
+		},
+		Caller: &runtime.Frame{
+			File: "pay.go",
+			Line: 42,
+		},
+	}
+}
+
+func TestTemplateFormatterGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{{
+		name: "default",
+		text: TemplateDefault,
+	}, {
+		name: "detail",
+		text: TemplateDetail,
+	}}
+
+	for _, test := range tests {
+		t.Log(test.name)
+
+		f, err := NewTemplateFormatter(test.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, attc, err := f.Format(newTemplateEntry())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert(t, (*Attachment)(nil), attc, true)
+
+		golden := filepath.Join("testdata", "templates", test.name+".golden")
+		if *_updateGolden {
+			err = os.WriteFile(golden, []byte(got), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		exp, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, string(exp), got, true)
+	}
+}
+
+func TestTemplateDefaultAsTextFormatter(t *testing.T) {
+	var (
+		entry = newTemplateEntry()
+		tf    = &TextFormatter{}
+	)
+
+	f, err := NewTemplateFormatter(TemplateDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp, _, _ := tf.Format(entry)
+	got, _, _ := f.Format(entry)
+
+	assert(t, exp, got, true)
+}
+
+func TestNewTemplateFormatter(t *testing.T) {
+	tests := []struct {
+		desc   string
+		text   string
+		expErr string
+		exp    string
+	}{{
+		desc:   "With invalid syntax",
+		text:   "{{icon .Level}",
+		expErr: `NewTemplateFormatter: template: mattermost:1: `,
+	}, {
+		desc:   "With unknown function",
+		text:   "{{upper .Message}}",
+		expErr: `function "upper" not defined`,
+	}, {
+		desc:   "With invalid field",
+		text:   "{{.Msg}}",
+		expErr: `can't evaluate field Msg in type *logrus.Entry`,
+	}, {
+		desc: "With functions",
+		text: `{{color .Level}} {{.Message | truncate 8 | escape}} {{field . "user"}}{{field . "none"}} {{time "15:04" .Time}}`,
+		exp:  `#9F6000 payment… a|b 10:04`,
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		f, err := NewTemplateFormatter(test.text)
+		if err != nil {
+			if len(test.expErr) == 0 ||
+				!strings.Contains(err.Error(), test.expErr) {
+				t.Fatalf("expecting error %q, got %q", test.expErr, err)
+			}
+			continue
+		}
+		if len(test.expErr) > 0 {
+			t.Fatalf("expecting error %q, got nil", test.expErr)
+		}
+
+		got, _, err := f.Format(newTemplateEntry())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestNewTemplateFormatterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "post.tmpl")
+
+	err := os.WriteFile(path, []byte("{{icon .Level}} {{.Message}}\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewTemplateFormatterFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, _ := f.Format(newTemplateEntry())
+	assert(t, ":interrobang: payment *failed* for [order]", got, true)
+
+	_, err = NewTemplateFormatterFile(filepath.Join(t.TempDir(), "none"))
+	if err == nil || !strings.HasPrefix(err.Error(), "NewTemplateFormatterFile: ") {
+		t.Fatalf("expecting error, got %v", err)
+	}
+}
+
+func TestNewWithTemplate(t *testing.T) {
+	_, err := New(Options{
+		Endpoint: "http://127.0.0.1",
+		Template: "{{.Unknown}}",
+	})
+	if err == nil {
+		t.Fatal("expecting error on invalid template")
+	}
+
+	hook, chanBody := newTestHook(t, Options{
+		Template: `{{icon .Level}} {{.Message | escape}}`,
+		MinLevel: logrus.InfoLevel,
+	})
+
+	err = hook.Fire(&logrus.Entry{
+		Level:   logrus.InfoLevel,
+		Message: "a_b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"username":"` + _hostname + `","text":":white_circle: a\\_b"}`
+	assert(t, exp, <-chanBody, true)
+}
+
+func TestNewWithTemplateOptions(t *testing.T) {
+	hook, _ := newTestHook(t, Options{
+		Template:     `{{caller .}}{{"\n"}}{{code (field . "error")}}`,
+		CodeMaxLines: 1,
+		Caller: CallerOptions{
+			URL:      "https://git.example.com/{file}#L{line}",
+			Revision: "v1",
+		},
+		MinLevel: logrus.InfoLevel,
+	})
+
+	entry := newTemplateEntry()
+
+	got, _, err := hook.opts.Formatter.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[pay.go:42](https://git.example.com/pay.go#L42)\n" +
+		record.CodeBlock(entry.Data[logrus.ErrorKey].(string), 1)
+
+	assert(t, exp, got, true)
+}
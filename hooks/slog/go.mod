@@ -0,0 +1,7 @@
+module github.com/shuLhan/mattermost-integration/hooks/slog
+
+go 1.21
+
+require github.com/shuLhan/mattermost-integration v0.0.0
+
+replace github.com/shuLhan/mattermost-integration => ../..
@@ -0,0 +1,308 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slog contains the log/slog Handler that send the log record to
+// Mattermost.
+//
+// The Handler convert each record into post and send it using the Sender
+// from package delivery, so it has the same asynchronous delivery, retry,
+// rate limit, overflow policy, spool, and metrics as the logrus hook,
+// without depends on logrus.
+//
+// # Example
+//
+//	import (
+//		"log/slog"
+//
+//		"github.com/shuLhan/mattermost-integration/delivery"
+//		mmslog "github.com/shuLhan/mattermost-integration/hooks/slog"
+//	)
+//
+//	func main() {
+//		handler, err := mmslog.New(mmslog.Options{
+//			Level:   slog.LevelWarn,
+//			Channel: "log_alpha",
+//			Delivery: delivery.Options{
+//				Endpoint: "https://my.mattermost.org/hooks/xxx",
+//			},
+//		})
+//		...
+//		defer handler.Close(context.Background())
+//
+//		logger := slog.New(handler)
+//		logger.Warn("disk almost full", "used", "95%")
+//	}
+package slog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// List of additional levels for fatal and panic level.
+// The record with these levels is displayed using the fatal and panic
+// icon and color, but the program is not terminated.
+const (
+	LevelFatal = slog.Level(12)
+	LevelPanic = slog.Level(16)
+)
+
+// Options define the options for creating new Handler.
+type Options struct {
+	// Level define the minimum level of record that will be send to
+	// Mattermost.
+	// Default to slog.LevelInfo.
+	Level slog.Leveler
+
+	// Attachment if not nil, each record will be send as attachment.
+	// The value will act as default attachment value, and it will
+	// replace the Color, Text, Fields, and Timestamp with the record.
+	Attachment *webhook.Attachment
+
+	// Channel define the channel name where the log will be send.
+	// If its empty then it will use the default channel defined in
+	// incoming webhook setting.
+	Channel string
+
+	// Username define the user name that send the log.
+	// If its empty then it will use the hostname.
+	Username string
+
+	// IconURL define the profile picture of the log sender.
+	IconURL string
+
+	// CodeMaxLines define the maximum number of lines of the error
+	// and multi-line field value, that rendered as code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+
+	// Oversize define the options to truncate or split the message that
+	// is larger than the maximum post size in Mattermost.
+	// By default, the message larger than 16383 characters is truncated.
+	Oversize webhook.OversizeOptions
+
+	// Delivery define the options to queue and send the message to
+	// Mattermost.
+	// The Delivery.Endpoint is required.
+	// If the Delivery.DropReport is nil, the number of dropped
+	// messages is reported as record with level warn.
+	Delivery delivery.Options
+
+	// AddSource if true, the source code position of log statement is
+	// rendered as caller.
+	AddSource bool
+
+	// CallerFooter if true, the caller in attachment mode is rendered
+	// in the footer instead of field.
+	CallerFooter bool
+}
+
+// validate check the required fields in Options.
+func (opts *Options) validate() (err error) {
+	if len(opts.Delivery.Endpoint) == 0 {
+		return webhook.ErrEndpointEmpty
+	}
+	return nil
+}
+
+// Handler implement the slog.Handler that send the log record to
+// Mattermost.
+type Handler struct {
+	sender *delivery.Sender
+	level  slog.Leveler
+	post   *record.Options
+
+	// data contains the attributes from WithAttrs, flatten with its
+	// group name.
+	data map[string]interface{}
+
+	// prefix contains the group names from WithGroup, joined with
+	// ".".
+	prefix string
+
+	addSource bool
+}
+
+// New create new Handler and start the underlying Sender.
+func New(opts Options) (h *Handler, err error) {
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	h = &Handler{
+		level: opts.Level,
+		post: &record.Options{
+			Attachment:   opts.Attachment,
+			Channel:      opts.Channel,
+			Username:     opts.Username,
+			IconURL:      opts.IconURL,
+			CodeMaxLines: opts.CodeMaxLines,
+			CallerFooter: opts.CallerFooter,
+			Oversize:     opts.Oversize,
+		},
+		addSource: opts.AddSource,
+	}
+	if h.level == nil {
+		h.level = slog.LevelInfo
+	}
+	if len(h.post.Username) == 0 {
+		h.post.Username, _ = os.Hostname()
+	}
+
+	if opts.Delivery.DropReport == nil {
+		opts.Delivery.DropReport = h.dropReport
+	}
+	opts.Delivery.Channel = opts.Channel
+
+	h.sender, err = delivery.New(opts.Delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Close the underlying Sender, see delivery.Sender.Close.
+func (h *Handler) Close(ctx context.Context) (dropped int, err error) {
+	return h.sender.Close(ctx)
+}
+
+// Sender return the underlying Sender, for example to Flush the messages
+// or to get the number of dropped messages.
+func (h *Handler) Sender() *delivery.Sender {
+	return h.sender
+}
+
+// Enabled return true if the `level` is equal or greater than the minimum
+// Level in Options.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle convert the slog record into post and send it to Mattermost.
+func (h *Handler) Handle(_ context.Context, rec slog.Record) error {
+	return h.record(rec).Push(h.sender, h.post)
+}
+
+// record convert the slog record into record.Record.
+func (h *Handler) record(rec slog.Record) (out *record.Record) {
+	out = &record.Record{
+		Time:    rec.Time,
+		Fields:  make(map[string]interface{}, len(h.data)+rec.NumAttrs()),
+		Message: rec.Message,
+		Level:   Level(rec.Level),
+	}
+
+	for k, v := range h.data {
+		out.Fields[k] = v
+	}
+	rec.Attrs(func(attr slog.Attr) bool {
+		appendAttr(out.Fields, h.prefix, attr)
+		return true
+	})
+
+	if h.addSource && rec.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{rec.PC})
+		frame, _ := frames.Next()
+		out.Caller = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+	}
+
+	return out
+}
+
+// WithAttrs return new Handler with the attributes added to each record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	clone := *h
+	clone.data = make(map[string]interface{}, len(h.data)+len(attrs))
+	for k, v := range h.data {
+		clone.data[k] = v
+	}
+	for _, attr := range attrs {
+		appendAttr(clone.data, h.prefix, attr)
+	}
+	return &clone
+}
+
+// WithGroup return new Handler where the attributes of next WithAttrs and
+// record are prefixed with the group `name`, for example "name.key".
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// dropReport return the post that report the number of dropped messages.
+func (h *Handler) dropReport(text string, total int64) *delivery.Post {
+	rec := &record.Record{
+		Time: time.Now(),
+		Fields: map[string]interface{}{
+			"dropped_total": total,
+		},
+		Message: text,
+		Level:   delivery.LevelWarning,
+	}
+	return rec.Posts(h.post)[0]
+}
+
+// Level convert the slog level into delivery level.
+func Level(level slog.Level) delivery.Level {
+	switch {
+	case level >= LevelPanic:
+		return delivery.LevelPanic
+	case level >= LevelFatal:
+		return delivery.LevelFatal
+	case level >= slog.LevelError:
+		return delivery.LevelError
+	case level >= slog.LevelWarn:
+		return delivery.LevelWarning
+	case level >= slog.LevelInfo:
+		return delivery.LevelInfo
+	case level >= slog.LevelDebug:
+		return delivery.LevelDebug
+	}
+	return delivery.LevelTrace
+}
+
+// appendAttr add the attribute into `data` with key prefixed by
+// `prefix`.
+// The group attribute is flatten, where each key is prefixed with the
+// group name.
+// The empty attribute and group are ignored, as defined in slog.Handler.
+func appendAttr(data map[string]interface{}, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		attrs := attr.Value.Group()
+		if len(attr.Key) > 0 {
+			prefix += attr.Key + "."
+		}
+		for _, sub := range attrs {
+			appendAttr(data, prefix, sub)
+		}
+		return
+	}
+
+	data[prefix+attr.Key] = attr.Value.Any()
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+// captureHandler record each log record converted by Handler, without
+// sending it.
+type captureHandler struct {
+	*Handler
+	records *[]*record.Record
+	locker  *sync.Mutex
+}
+
+func (h *captureHandler) Handle(_ context.Context, rec slog.Record) error {
+	h.locker.Lock()
+	*h.records = append(*h.records, h.record(rec))
+	h.locker.Unlock()
+	return nil
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.Handler = h.Handler.WithAttrs(attrs).(*Handler)
+	return &clone
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.Handler = h.Handler.WithGroup(name).(*Handler)
+	return &clone
+}
+
+// newCaptureHandler create new captureHandler from Handler.
+func newCaptureHandler(h *Handler) *captureHandler {
+	return &captureHandler{
+		Handler: h,
+		records: &[]*record.Record{},
+		locker:  &sync.Mutex{},
+	}
+}
+
+// newTestHandler create new Handler that send the message to test server.
+func newTestHandler(t *testing.T, opts Options) (
+	h *Handler, chanBody chan string,
+) {
+	chanBody = make(chan string, 30)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			chanBody <- string(body)
+		}))
+	t.Cleanup(srv.Close)
+
+	opts.Delivery.Endpoint = srv.URL
+
+	h, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = h.Close(context.Background())
+	})
+
+	return h, chanBody
+}
+
+func TestHandlerSlogtest(t *testing.T) {
+	h, _ := newTestHandler(t, Options{Level: slog.LevelDebug})
+	ch := newCaptureHandler(h)
+
+	results := func() (list []map[string]any) {
+		for _, rec := range *ch.records {
+			m := map[string]any{
+				slog.LevelKey:   rec.Level,
+				slog.MessageKey: rec.Message,
+			}
+			if !rec.Time.IsZero() {
+				m[slog.TimeKey] = rec.Time
+			}
+			for k, v := range rec.Fields {
+				// Convert the flatten key "g.a" into nested map.
+				var (
+					names = strings.Split(k, ".")
+					sub   = m
+				)
+				for _, name := range names[:len(names)-1] {
+					next, ok := sub[name].(map[string]any)
+					if !ok {
+						next = map[string]any{}
+						sub[name] = next
+					}
+					sub = next
+				}
+				sub[names[len(names)-1]] = v
+			}
+			list = append(list, m)
+		}
+		return list
+	}
+
+	err := slogtest.TestHandler(ch, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h, chanBody := newTestHandler(t, Options{
+		Level: slog.LevelWarn,
+	})
+
+	logger := slog.New(h).With("app", "billing").WithGroup("req")
+
+	logger.Info("not send")
+	logger.Warn("slow request", "path", "/pay",
+		slog.Group("user", "id", 7))
+	logger.Log(context.Background(), LevelFatal, "out of memory")
+
+	hostname, _ := os.Hostname()
+	exp := []string{
+		`{"username":"` + hostname + `","text":":interrobang: app=billing req.path=/pay req.user.id=7 msg=slow request"}`,
+		`{"username":"` + hostname + `","text":":bangbang: app=billing msg=out of memory"}`,
+	}
+	got := []string{<-chanBody, <-chanBody}
+
+	if got[0] != exp[0] {
+		got[0], got[1] = got[1], got[0]
+	}
+	assert(t, exp, got, true)
+}
+
+func TestHandlerAddSource(t *testing.T) {
+	h, _ := newTestHandler(t, Options{
+		AddSource: true,
+	})
+	ch := newCaptureHandler(h)
+
+	slog.New(ch).Error("failed")
+
+	caller := (*ch.records)[0].Caller
+	if !strings.Contains(caller, "slog_test.go:") {
+		t.Fatalf("unexpected caller %q", caller)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		in  slog.Level
+		exp delivery.Level
+	}{
+		{in: slog.LevelDebug - 4, exp: delivery.LevelTrace},
+		{in: slog.LevelDebug, exp: delivery.LevelDebug},
+		{in: slog.LevelInfo + 1, exp: delivery.LevelInfo},
+		{in: slog.LevelWarn, exp: delivery.LevelWarning},
+		{in: slog.LevelError, exp: delivery.LevelError},
+		{in: LevelFatal, exp: delivery.LevelFatal},
+		{in: LevelPanic + 4, exp: delivery.LevelPanic},
+	}
+
+	for _, test := range tests {
+		t.Log(test.in)
+		assert(t, test.exp, Level(test.in), true)
+	}
+}
+
+func TestNewWithoutEndpoint(t *testing.T) {
+	_, err := New(Options{})
+	assert(t, webhook.ErrEndpointEmpty, err, true)
+}
+
+func TestHandlerEnabled(t *testing.T) {
+	h, _ := newTestHandler(t, Options{})
+
+	ctx := context.Background()
+	assert(t, false, h.Enabled(ctx, slog.LevelDebug), true)
+	assert(t, true, h.Enabled(ctx, slog.LevelInfo), true)
+}
@@ -0,0 +1,12 @@
+module github.com/shuLhan/mattermost-integration/hooks/zap
+
+go 1.19
+
+require (
+	github.com/shuLhan/mattermost-integration v0.0.0
+	go.uber.org/zap v1.26.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/shuLhan/mattermost-integration => ../..
@@ -0,0 +1,316 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zap contains the zapcore.Core that send the log entry to
+// Mattermost.
+//
+// The Core convert each zap entry into post and send it using the Sender
+// from package delivery, so it has the same asynchronous delivery, retry,
+// rate limit, overflow policy, spool, and metrics as the logrus hook,
+// without depends on logrus.
+//
+// # Example
+//
+//	core, err := mmzap.New(mmzap.Options{
+//		Level: zapcore.WarnLevel,
+//		Delivery: delivery.Options{
+//			Endpoint: "https://my.mattermost.org/hooks/xxx",
+//		},
+//	})
+//	...
+//	defer core.Close(context.Background())
+//
+//	logger := zap.New(zapcore.NewTee(stderrCore, core))
+//	logger.Warn("disk almost full", zap.String("used", "95%"))
+package zap
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+	"go.uber.org/zap/zapcore"
+)
+
+// List of field names for zap entry.
+const (
+	// FieldLogger define the field name of logger name.
+	FieldLogger = "logger"
+
+	// FieldStacktrace define the field name of stack trace.
+	FieldStacktrace = "stacktrace"
+)
+
+// defFlushTimeout define the default maximum duration to wait for queued
+// messages before zap exit or panic.
+const defFlushTimeout = 5 * time.Second
+
+// Options define the options for creating new Core.
+type Options struct {
+	// Level define the minimum level of entry that will be send to
+	// Mattermost.
+	// Default to zapcore.InfoLevel.
+	Level zapcore.LevelEnabler
+
+	// Attachment if not nil, each entry will be send as attachment.
+	// The value will act as default attachment value, and it will
+	// replace the Color, Text, Fields, and Timestamp with the entry.
+	Attachment *webhook.Attachment
+
+	// Channel define the channel name where the log will be send.
+	// If its empty then it will use the default channel defined in
+	// incoming webhook setting.
+	Channel string
+
+	// Username define the user name that send the log.
+	// If its empty then it will use the hostname.
+	Username string
+
+	// IconURL define the profile picture of the log sender.
+	IconURL string
+
+	// CodeMaxLines define the maximum number of lines of the error
+	// and multi-line field value, that rendered as code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+
+	// Oversize define the options to truncate or split the message that
+	// is larger than the maximum post size in Mattermost.
+	// By default, the message larger than 16383 characters is truncated.
+	Oversize webhook.OversizeOptions
+
+	// CallerFooter if true, the caller in attachment mode is rendered
+	// in the footer instead of field.
+	CallerFooter bool
+
+	// FlushTimeout define the maximum duration to wait for queued
+	// messages to be send, when writing the entry with level DPanic,
+	// Panic, or Fatal, before zap panic or exit the program.
+	// Default to 5 seconds.
+	FlushTimeout time.Duration
+
+	// Delivery define the options to queue and send the message to
+	// Mattermost.
+	// The Delivery.Endpoint is required.
+	// If the Delivery.DropReport is nil, the number of dropped
+	// messages is reported as entry with level warn.
+	Delivery delivery.Options
+}
+
+// validate check the required fields in Options.
+func (opts *Options) validate() (err error) {
+	if len(opts.Delivery.Endpoint) == 0 {
+		return webhook.ErrEndpointEmpty
+	}
+	return nil
+}
+
+// Core implement the zapcore.Core that send the log entry to Mattermost.
+type Core struct {
+	sender *delivery.Sender
+	level  zapcore.LevelEnabler
+	post   *record.Options
+
+	// fields contains the fields from With.
+	fields map[string]interface{}
+
+	flushTimeout time.Duration
+}
+
+// New create new Core and start the underlying Sender.
+func New(opts Options) (core *Core, err error) {
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	core = &Core{
+		level: opts.Level,
+		post: &record.Options{
+			Attachment:   opts.Attachment,
+			Channel:      opts.Channel,
+			Username:     opts.Username,
+			IconURL:      opts.IconURL,
+			CodeMaxLines: opts.CodeMaxLines,
+			CallerFooter: opts.CallerFooter,
+			Oversize:     opts.Oversize,
+		},
+		fields: map[string]interface{}{},
+
+		flushTimeout: opts.FlushTimeout,
+	}
+	if core.level == nil {
+		core.level = zapcore.InfoLevel
+	}
+	if core.flushTimeout <= 0 {
+		core.flushTimeout = defFlushTimeout
+	}
+	if len(core.post.Username) == 0 {
+		core.post.Username, _ = os.Hostname()
+	}
+
+	if opts.Delivery.DropReport == nil {
+		opts.Delivery.DropReport = core.dropReport
+	}
+	opts.Delivery.Channel = opts.Channel
+
+	core.sender, err = delivery.New(opts.Delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return core, nil
+}
+
+// Close the underlying Sender, see delivery.Sender.Close.
+func (core *Core) Close(ctx context.Context) (dropped int, err error) {
+	return core.sender.Close(ctx)
+}
+
+// Sender return the underlying Sender, for example to Flush the messages
+// or to get the number of dropped messages.
+func (core *Core) Sender() *delivery.Sender {
+	return core.sender
+}
+
+// Enabled return true if the `level` is enabled by the Level in Options.
+func (core *Core) Enabled(level zapcore.Level) bool {
+	return core.level.Enabled(level)
+}
+
+// With return new Core with the fields added to each entry.
+func (core *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *core
+	clone.fields = core.encode(fields)
+	return &clone
+}
+
+// Check add the Core into checked entry if the entry level is enabled.
+func (core *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(ent.Level) {
+		return ce.AddCore(ent, core)
+	}
+	return ce
+}
+
+// Write convert the zap entry and fields into post and send it to
+// Mattermost.
+//
+// If the entry level is DPanic, Panic, or Fatal, it will wait until all
+// queued messages has been send or the FlushTimeout in Options, since zap
+// may panic or exit the program after Write return.
+func (core *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	rec := &record.Record{
+		Time:    ent.Time,
+		Fields:  core.encode(fields),
+		Message: ent.Message,
+		Level:   Level(ent.Level),
+	}
+
+	if len(ent.LoggerName) > 0 {
+		rec.Fields[FieldLogger] = ent.LoggerName
+	}
+	if len(ent.Stack) > 0 {
+		rec.Fields[FieldStacktrace] = ent.Stack
+	}
+	if ent.Caller.Defined {
+		rec.Caller = ent.Caller.TrimmedPath()
+	}
+
+	err := rec.Push(core.sender, core.post)
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		err = core.flush()
+	}
+	return err
+}
+
+// Sync wait until all queued messages has been send, see
+// delivery.Sender.Flush.
+func (core *Core) Sync() error {
+	_, err := core.sender.Flush(context.Background())
+	return err
+}
+
+// flush wait until all queued messages has been send, limited by
+// flushTimeout.
+func (core *Core) flush() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), core.flushTimeout)
+	defer cancel()
+
+	_, err = core.sender.Flush(ctx)
+	return err
+}
+
+// dropReport return the post that report the number of dropped messages.
+func (core *Core) dropReport(text string, total int64) *delivery.Post {
+	rec := &record.Record{
+		Time: time.Now(),
+		Fields: map[string]interface{}{
+			"dropped_total": total,
+		},
+		Message: text,
+		Level:   delivery.LevelWarning,
+	}
+	return rec.Posts(core.post)[0]
+}
+
+// encode the fields from With and `fields` into map.
+// The field inside namespace is flatten, where its key is prefixed with
+// the namespace, for example "ns.key".
+func (core *Core) encode(fields []zapcore.Field) (data map[string]interface{}) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	data = make(map[string]interface{}, len(core.fields)+len(enc.Fields))
+	for k, v := range core.fields {
+		data[k] = v
+	}
+	flatten(data, "", enc.Fields)
+
+	return data
+}
+
+// flatten add the values in `in` into `data`, where the nested map is
+// flatten with key prefixed by its parent key.
+func flatten(data map[string]interface{}, prefix string, in map[string]interface{}) {
+	for k, v := range in {
+		sub, ok := v.(map[string]interface{})
+		if ok {
+			flatten(data, prefix+k+".", sub)
+			continue
+		}
+		data[prefix+k] = v
+	}
+}
+
+// Level convert the zap level into delivery level.
+func Level(level zapcore.Level) delivery.Level {
+	switch level {
+	case zapcore.DebugLevel:
+		return delivery.LevelDebug
+	case zapcore.InfoLevel:
+		return delivery.LevelInfo
+	case zapcore.WarnLevel:
+		return delivery.LevelWarning
+	case zapcore.ErrorLevel:
+		return delivery.LevelError
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return delivery.LevelPanic
+	case zapcore.FatalLevel:
+		return delivery.LevelFatal
+	}
+	if level < zapcore.DebugLevel {
+		return delivery.LevelTrace
+	}
+	return delivery.LevelPanic
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/webhook"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+// newTestCore create new Core that send the message to test server.
+func newTestCore(t *testing.T, opts Options) (
+	core *Core, chanBody chan string,
+) {
+	chanBody = make(chan string, 30)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			chanBody <- string(body)
+		}))
+	t.Cleanup(srv.Close)
+
+	opts.Delivery.Endpoint = srv.URL
+
+	core, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = core.Close(context.Background())
+	})
+
+	return core, chanBody
+}
+
+func TestCore(t *testing.T) {
+	core, chanBody := newTestCore(t, Options{
+		Level: zapcore.WarnLevel,
+	})
+
+	logger := zap.New(core).Named("billing").With(zap.Int("shard", 2))
+
+	logger.Info("not send")
+	logger.Warn("slow request",
+		zap.Namespace("req"),
+		zap.String("path", "/pay"))
+	logger.Error("payment failed", zap.Error(errors.New("card declined")))
+
+	err := logger.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostname, _ := os.Hostname()
+	exp := []string{
+		`{"username":"` + hostname + `","text":":exclamation: logger=billing shard=2 msg=payment failed\nerror:\n` + "```" + `\ncard declined\n` + "```" + `"}`,
+		`{"username":"` + hostname + `","text":":interrobang: logger=billing req.path=/pay shard=2 msg=slow request"}`,
+	}
+	got := []string{<-chanBody, <-chanBody}
+	sort.Strings(got)
+
+	assert(t, exp, got, true)
+}
+
+func TestCoreWriteFlush(t *testing.T) {
+	core, chanBody := newTestCore(t, Options{})
+
+	// DPanic does not panic on production logger, but it is flushed
+	// the same as Panic and Fatal.
+	logger := zap.New(core)
+	logger.DPanic("invalid state")
+
+	select {
+	case got := <-chanBody:
+		if !strings.Contains(got, "msg=invalid state") {
+			t.Fatalf("unexpected body %s", got)
+		}
+	default:
+		t.Fatal("expecting DPanic entry has been send before return")
+	}
+}
+
+func TestCoreCaller(t *testing.T) {
+	core, chanBody := newTestCore(t, Options{
+		Attachment:   &webhook.Attachment{},
+		CallerFooter: true,
+	})
+
+	logger := zap.New(core, zap.AddCaller())
+	logger.Info("started")
+
+	_ = logger.Sync()
+
+	got := <-chanBody
+	if !strings.Contains(got, `"footer":"`) ||
+		!strings.Contains(got, `zap_test.go:`) {
+		t.Fatalf("expecting caller in footer, got %s", got)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		in  zapcore.Level
+		exp delivery.Level
+	}{
+		{in: zapcore.DebugLevel - 1, exp: delivery.LevelTrace},
+		{in: zapcore.DebugLevel, exp: delivery.LevelDebug},
+		{in: zapcore.InfoLevel, exp: delivery.LevelInfo},
+		{in: zapcore.WarnLevel, exp: delivery.LevelWarning},
+		{in: zapcore.ErrorLevel, exp: delivery.LevelError},
+		{in: zapcore.DPanicLevel, exp: delivery.LevelPanic},
+		{in: zapcore.PanicLevel, exp: delivery.LevelPanic},
+		{in: zapcore.FatalLevel, exp: delivery.LevelFatal},
+	}
+
+	for _, test := range tests {
+		t.Log(test.in)
+		assert(t, test.exp, Level(test.in), true)
+	}
+}
+
+func TestNewWithoutEndpoint(t *testing.T) {
+	_, err := New(Options{})
+	assert(t, webhook.ErrEndpointEmpty, err, true)
+}
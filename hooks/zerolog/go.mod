@@ -0,0 +1,16 @@
+module github.com/shuLhan/mattermost-integration/hooks/zerolog
+
+go 1.18
+
+require (
+	github.com/rs/zerolog v1.31.0
+	github.com/shuLhan/mattermost-integration v0.0.0
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+)
+
+replace github.com/shuLhan/mattermost-integration => ../..
@@ -0,0 +1,331 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zerolog contains the zerolog.LevelWriter that send the log event
+// to Mattermost.
+//
+// The Writer parse each JSON event from zerolog into post and send it
+// using the Sender from package delivery, so it has the same asynchronous
+// delivery, retry, rate limit, overflow policy, spool, and metrics as the
+// logrus hook, without depends on logrus.
+//
+// # Example
+//
+//	w, err := mmzerolog.New(mmzerolog.Options{
+//		Level: zerolog.WarnLevel,
+//		Delivery: delivery.Options{
+//			Endpoint: "https://my.mattermost.org/hooks/xxx",
+//		},
+//	})
+//	...
+//	defer w.Close(context.Background())
+//
+//	logger := zerolog.New(zerolog.MultiLevelWriter(os.Stderr, w)).
+//		With().Timestamp().Logger()
+//	logger.Warn().Str("used", "95%").Msg("disk almost full")
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/hooks/internal/record"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+// defFlushTimeout define the default maximum duration to wait for queued
+// messages before zerolog exit or panic.
+const defFlushTimeout = 5 * time.Second
+
+// Options define the options for creating new Writer.
+type Options struct {
+	// Attachment if not nil, each event will be send as attachment.
+	// The value will act as default attachment value, and it will
+	// replace the Color, Text, Fields, and Timestamp with the event.
+	Attachment *webhook.Attachment
+
+	// Channel define the channel name where the log will be send.
+	// If its empty then it will use the default channel defined in
+	// incoming webhook setting.
+	Channel string
+
+	// Username define the user name that send the log.
+	// If its empty then it will use the hostname.
+	Username string
+
+	// IconURL define the profile picture of the log sender.
+	IconURL string
+
+	// CodeMaxLines define the maximum number of lines of the error
+	// and multi-line field value, that rendered as code block.
+	// Default to 30.
+	// Set to negative value to render all lines.
+	CodeMaxLines int
+
+	// Oversize define the options to truncate or split the message that
+	// is larger than the maximum post size in Mattermost.
+	// By default, the message larger than 16383 characters is truncated.
+	Oversize webhook.OversizeOptions
+
+	// CallerFooter if true, the caller in attachment mode is rendered
+	// in the footer instead of field.
+	CallerFooter bool
+
+	// Level define the minimum level of event that will be send to
+	// Mattermost.
+	// Since the zero value is zerolog.DebugLevel, the default is all
+	// events, except trace, are send.
+	// The event without level, zerolog.NoLevel, is send with level
+	// info.
+	Level zerolog.Level
+
+	// FlushTimeout define the maximum duration to wait for queued
+	// messages to be send, when writing the event with level fatal or
+	// panic, before zerolog exit the program or panic.
+	// Default to 5 seconds.
+	FlushTimeout time.Duration
+
+	// Delivery define the options to queue and send the message to
+	// Mattermost.
+	// The Delivery.Endpoint is required.
+	// If the Delivery.DropReport is nil, the number of dropped
+	// messages is reported as event with level warn.
+	Delivery delivery.Options
+}
+
+// validate check the required fields in Options.
+func (opts *Options) validate() (err error) {
+	if len(opts.Delivery.Endpoint) == 0 {
+		return webhook.ErrEndpointEmpty
+	}
+	return nil
+}
+
+// Writer implement the zerolog.LevelWriter that send the log event to
+// Mattermost.
+type Writer struct {
+	sender *delivery.Sender
+	post   *record.Options
+	level  zerolog.Level
+
+	flushTimeout time.Duration
+}
+
+// New create new Writer and start the underlying Sender.
+func New(opts Options) (w *Writer, err error) {
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	w = &Writer{
+		post: &record.Options{
+			Attachment:   opts.Attachment,
+			Channel:      opts.Channel,
+			Username:     opts.Username,
+			IconURL:      opts.IconURL,
+			CodeMaxLines: opts.CodeMaxLines,
+			CallerFooter: opts.CallerFooter,
+			Oversize:     opts.Oversize,
+		},
+		level: opts.Level,
+
+		flushTimeout: opts.FlushTimeout,
+	}
+	if w.flushTimeout <= 0 {
+		w.flushTimeout = defFlushTimeout
+	}
+	if len(w.post.Username) == 0 {
+		w.post.Username, _ = os.Hostname()
+	}
+
+	if opts.Delivery.DropReport == nil {
+		opts.Delivery.DropReport = w.dropReport
+	}
+	opts.Delivery.Channel = opts.Channel
+
+	w.sender, err = delivery.New(opts.Delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Close the underlying Sender, see delivery.Sender.Close.
+func (w *Writer) Close(ctx context.Context) (dropped int, err error) {
+	return w.sender.Close(ctx)
+}
+
+// Sender return the underlying Sender, for example to Flush the messages
+// or to get the number of dropped messages.
+func (w *Writer) Sender() *delivery.Sender {
+	return w.sender
+}
+
+// Write parse the JSON event and send it to Mattermost, using the level
+// from the event.
+//
+// If the event level is fatal or panic, it will wait until all queued
+// messages has been send or the FlushTimeout in Options, since zerolog
+// may exit the program or panic after Write return.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	return w.write(p, zerolog.NoLevel)
+}
+
+// WriteLevel parse the JSON event and send it to Mattermost, if the
+// `level` is enabled.
+// The event with level fatal or panic is flushed, see Write.
+func (w *Writer) WriteLevel(level zerolog.Level, p []byte) (n int, err error) {
+	return w.write(p, level)
+}
+
+func (w *Writer) write(p []byte, level zerolog.Level) (n int, err error) {
+	var logp = "zerolog.Write"
+
+	rec, err := w.parse(p, level)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logp, err)
+	}
+	if rec != nil {
+		err = rec.Push(w.sender, w.post)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", logp, err)
+		}
+		if rec.Level <= delivery.LevelFatal {
+			err = w.flush()
+			if err != nil {
+				return 0, fmt.Errorf("%s: %w", logp, err)
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// flush wait until all queued messages has been send, limited by
+// flushTimeout.
+func (w *Writer) flush() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.flushTimeout)
+	defer cancel()
+
+	_, err = w.sender.Flush(ctx)
+	return err
+}
+
+// dropReport return the post that report the number of dropped messages.
+func (w *Writer) dropReport(text string, total int64) *delivery.Post {
+	rec := &record.Record{
+		Time: time.Now(),
+		Fields: map[string]interface{}{
+			"dropped_total": total,
+		},
+		Message: text,
+		Level:   delivery.LevelWarning,
+	}
+	return rec.Posts(w.post)[0]
+}
+
+// parse the JSON event into record.
+// It return nil record if the level is not enabled.
+// If `level` is NoLevel, the level is parsed from event.
+func (w *Writer) parse(p []byte, level zerolog.Level) (
+	rec *record.Record, err error,
+) {
+	var (
+		dec  = json.NewDecoder(bytes.NewReader(p))
+		data = map[string]interface{}{}
+	)
+
+	dec.UseNumber()
+	err = dec.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	if level == zerolog.NoLevel {
+		str, _ := data[zerolog.LevelFieldName].(string)
+		level, err = zerolog.ParseLevel(str)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if level == zerolog.Disabled {
+		return nil, nil
+	}
+	if level == zerolog.NoLevel {
+		level = zerolog.InfoLevel
+	}
+	if level < w.level {
+		return nil, nil
+	}
+
+	rec = &record.Record{
+		Fields: data,
+		Level:  Level(level),
+	}
+
+	rec.Message, _ = data[zerolog.MessageFieldName].(string)
+	delete(data, zerolog.MessageFieldName)
+	delete(data, zerolog.LevelFieldName)
+
+	str, ok := data[zerolog.TimestampFieldName].(string)
+	if ok {
+		rec.Time, err = time.Parse(time.RFC3339Nano, str)
+		if err == nil {
+			delete(data, zerolog.TimestampFieldName)
+		}
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	str, ok = data[zerolog.CallerFieldName].(string)
+	if ok && isCaller(str) {
+		rec.Caller = str
+		delete(data, zerolog.CallerFieldName)
+	}
+
+	return rec, nil
+}
+
+// isCaller return true if the `caller` is in the format "file:line".
+func isCaller(caller string) bool {
+	x := strings.LastIndexByte(caller, ':')
+	if x <= 0 {
+		return false
+	}
+	_, err := strconv.Atoi(caller[x+1:])
+	return err == nil
+}
+
+// Level convert the zerolog level into delivery level.
+// The NoLevel is converted into info level.
+func Level(level zerolog.Level) delivery.Level {
+	switch level {
+	case zerolog.TraceLevel:
+		return delivery.LevelTrace
+	case zerolog.DebugLevel:
+		return delivery.LevelDebug
+	case zerolog.WarnLevel:
+		return delivery.LevelWarning
+	case zerolog.ErrorLevel:
+		return delivery.LevelError
+	case zerolog.FatalLevel:
+		return delivery.LevelFatal
+	case zerolog.PanicLevel:
+		return delivery.LevelPanic
+	}
+	if level < zerolog.TraceLevel {
+		return delivery.LevelTrace
+	}
+	return delivery.LevelInfo
+}
@@ -0,0 +1,262 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zerolog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/shuLhan/mattermost-integration/delivery"
+	"github.com/shuLhan/mattermost-integration/webhook"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+// newTestWriter create new Writer that send the message to test server.
+func newTestWriter(t *testing.T, opts Options) (
+	w *Writer, chanBody chan string,
+) {
+	chanBody = make(chan string, 30)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			chanBody <- string(body)
+		}))
+	t.Cleanup(srv.Close)
+
+	opts.Delivery.Endpoint = srv.URL
+
+	w, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = w.Close(context.Background())
+	})
+
+	return w, chanBody
+}
+
+func TestWriter(t *testing.T) {
+	w, chanBody := newTestWriter(t, Options{
+		Level: zerolog.WarnLevel,
+	})
+
+	logger := zerolog.New(w).With().Str("service", "billing").Logger()
+
+	logger.Info().Msg("not send")
+	logger.Warn().Str("path", "/pay").Int("shard", 2).Msg("slow request")
+	logger.Error().Err(errors.New("card declined")).Msg("payment failed")
+
+	_, err := w.Sender().Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostname, _ := os.Hostname()
+	exp := []string{
+		`{"username":"` + hostname + `","text":":exclamation: service=billing msg=payment failed\nerror:\n` + "```" + `\ncard declined\n` + "```" + `"}`,
+		`{"username":"` + hostname + `","text":":interrobang: path=/pay service=billing shard=2 msg=slow request"}`,
+	}
+	got := []string{<-chanBody, <-chanBody}
+	sort.Strings(got)
+
+	assert(t, exp, got, true)
+}
+
+func TestWriterFlush(t *testing.T) {
+	w, chanBody := newTestWriter(t, Options{})
+
+	// WithLevel does not exit or panic, but the event is flushed the
+	// same as Fatal and Panic.
+	logger := zerolog.New(w)
+	logger.WithLevel(zerolog.FatalLevel).Msg("out of memory")
+
+	select {
+	case got := <-chanBody:
+		if !strings.Contains(got, "msg=out of memory") {
+			t.Fatalf("unexpected body %s", got)
+		}
+	default:
+		t.Fatal("expecting fatal event has been send before return")
+	}
+}
+
+func TestWriterParse(t *testing.T) {
+	w := &Writer{
+		level: zerolog.InfoLevel,
+	}
+
+	type testCase struct {
+		desc     string
+		in       string
+		level    zerolog.Level
+		expLevel delivery.Level
+		expMsg   string
+		expData  map[string]interface{}
+		expNil   bool
+		expError bool
+	}
+
+	cases := []testCase{{
+		desc:     "With level from event",
+		in:       `{"level":"error","message":"failed","key":"value"}`,
+		level:    zerolog.NoLevel,
+		expLevel: delivery.LevelError,
+		expMsg:   "failed",
+		expData:  map[string]interface{}{"key": "value"},
+	}, {
+		desc:     "With level from WriteLevel",
+		in:       `{"level":"error","message":"failed"}`,
+		level:    zerolog.WarnLevel,
+		expLevel: delivery.LevelWarning,
+		expMsg:   "failed",
+		expData:  map[string]interface{}{},
+	}, {
+		desc:     "Without level",
+		in:       `{"message":"started"}`,
+		level:    zerolog.NoLevel,
+		expLevel: delivery.LevelInfo,
+		expMsg:   "started",
+		expData:  map[string]interface{}{},
+	}, {
+		desc:   "With level below minimum",
+		in:     `{"level":"debug","message":"not send"}`,
+		level:  zerolog.NoLevel,
+		expNil: true,
+	}, {
+		desc:   "With disabled level",
+		in:     `{"message":"not send"}`,
+		level:  zerolog.Disabled,
+		expNil: true,
+	}, {
+		desc:     "With invalid level",
+		in:       `{"level":"unknown"}`,
+		level:    zerolog.NoLevel,
+		expError: true,
+	}, {
+		desc:     "With invalid JSON",
+		in:       `{"level":`,
+		level:    zerolog.NoLevel,
+		expError: true,
+	}, {
+		desc:     "With number and invalid time",
+		in:       `{"level":"warn","n":10,"time":"yesterday"}`,
+		level:    zerolog.NoLevel,
+		expLevel: delivery.LevelWarning,
+		expData: map[string]interface{}{
+			"n":    json.Number("10"),
+			"time": "yesterday",
+		},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		rec, err := w.parse([]byte(c.in), c.level)
+		if c.expError {
+			assert(t, true, err != nil, true)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.expNil {
+			assert(t, true, rec == nil, true)
+			continue
+		}
+
+		assert(t, c.expLevel, rec.Level, true)
+		assert(t, c.expMsg, rec.Message, true)
+		assert(t, c.expData, rec.Fields, true)
+	}
+}
+
+func TestWriterParseTimeCaller(t *testing.T) {
+	w := &Writer{}
+
+	in := `{"level":"info","time":"2023-02-18T10:00:00Z","caller":"/src/main.go:12"}`
+
+	rec, err := w.parse([]byte(in), zerolog.NoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, "2023-02-18T10:00:00Z", rec.Time.Format("2006-01-02T15:04:05Z07:00"), true)
+	assert(t, "/src/main.go:12", rec.Caller, true)
+	assert(t, map[string]interface{}{}, rec.Fields, true)
+}
+
+func TestIsCaller(t *testing.T) {
+	type testCase struct {
+		in  string
+		exp bool
+	}
+
+	cases := []testCase{{
+		in:  "main.go:12",
+		exp: true,
+	}, {
+		in:  `C:\src\main.go:7`,
+		exp: true,
+	}, {
+		in: "main.go",
+	}, {
+		in: ":12",
+	}, {
+		in: "main.go:x",
+	}}
+
+	for _, c := range cases {
+		t.Log(c.in)
+		assert(t, c.exp, isCaller(c.in), true)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		in  zerolog.Level
+		exp delivery.Level
+	}{
+		{in: zerolog.TraceLevel - 1, exp: delivery.LevelTrace},
+		{in: zerolog.TraceLevel, exp: delivery.LevelTrace},
+		{in: zerolog.DebugLevel, exp: delivery.LevelDebug},
+		{in: zerolog.InfoLevel, exp: delivery.LevelInfo},
+		{in: zerolog.WarnLevel, exp: delivery.LevelWarning},
+		{in: zerolog.ErrorLevel, exp: delivery.LevelError},
+		{in: zerolog.FatalLevel, exp: delivery.LevelFatal},
+		{in: zerolog.PanicLevel, exp: delivery.LevelPanic},
+		{in: zerolog.NoLevel, exp: delivery.LevelInfo},
+	}
+
+	for _, test := range tests {
+		t.Log(test.in)
+		assert(t, test.exp, Level(test.in), true)
+	}
+}
+
+func TestNewWithoutEndpoint(t *testing.T) {
+	_, err := New(Options{})
+	assert(t, webhook.ErrEndpointEmpty, err, true)
+}
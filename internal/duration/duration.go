@@ -0,0 +1,25 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package duration contains the helper for time.Duration that are shared
+// by the hooks and the action handler.
+package duration
+
+import (
+	"strings"
+	"time"
+)
+
+// Format format duration `d` without zero units, for example 5m0s become
+// "5m" and 1h0m0s become "1h".
+func Format(d time.Duration) (s string) {
+	s = d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
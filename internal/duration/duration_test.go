@@ -0,0 +1,31 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package duration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		exp string
+		in  time.Duration
+	}{
+		{in: 30 * time.Second, exp: "30s"},
+		{in: 5 * time.Minute, exp: "5m"},
+		{in: 90 * time.Second, exp: "1m30s"},
+		{in: time.Hour, exp: "1h"},
+		{in: 90 * time.Minute, exp: "1h30m"},
+	}
+
+	for _, test := range tests {
+		got := Format(test.in)
+		if got != test.exp {
+			t.Fatalf("Format(%s): expecting %q, got %q", test.in,
+				test.exp, got)
+		}
+	}
+}
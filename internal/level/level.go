@@ -0,0 +1,34 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package level contains the icon and color of each log level that are
+// shared by the hooks and the mm command, so the post from all of them
+// look the same.
+package level
+
+var (
+	// Icons contains the icon displayed before the log message,
+	// indexed by delivery.Level.
+	Icons = []string{
+		":x:",            // Panic
+		":bangbang:",     // Fatal
+		":exclamation:",  // Error
+		":interrobang:",  // Warning
+		":white_circle:", // Info
+		":black_circle:", // Debug
+		":mag_right:",    // Trace
+	}
+
+	// Colors contains the color of attachment, indexed by
+	// delivery.Level.
+	Colors = []string{
+		"#FF0000", // Panic
+		"#CC0000", // Fatal
+		"#990000", // Error
+		"#9F6000", // Warning
+		"#FFFFFF", // Info
+		"#000000", // Debug
+		"#000000", // Trace
+	}
+)
@@ -2,6 +2,9 @@
 ##
 ## mm.sh: script to send any text to Mattermost.
 ##
+## DEPRECATED: use the Go command in cmd/mm, which encode the JSON properly,
+## verify the server certificate, and split large text into multiple posts.
+##
 ## Parameters,
 ##
 ##  1: error level in string "INFO | ERROR", default to INFO
@@ -0,0 +1,134 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package build contains the example of slash command "/build", ported
+// from the Buildbot change hook in buildbot/mattermost.py.
+//
+// The command format is,
+//
+//	/build <repo> [branch] [revision]
+//
+// The branch is default to "master" and the revision is default to empty,
+// which means the latest revision.
+// Each command create a Change that passed to the Trigger function, for
+// example to force a build in Buildbot or other CI.
+//
+// # Example
+//
+//	cmd, err := build.Command(build.Options{
+//		RepoBaseURL: "https://git.example.com/",
+//		Trigger: func(change *build.Change) error {
+//			return ci.Build(change.Repository, change.Branch,
+//				change.Revision)
+//		},
+//	})
+//	...
+//	srv.Handle("/build", cmd)
+package build
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shuLhan/mattermost-integration/slash"
+)
+
+const (
+	defBranch   = "master"
+	defCategory = "mattermost"
+	defComments = "Build from mattermost"
+)
+
+// List of errors.
+var (
+	ErrRepoBaseURLEmpty = errors.New("empty RepoBaseURL")
+	ErrTriggerNil       = errors.New("nil Trigger")
+	ErrMissingRepo      = errors.New("usage: /build <repo> [branch] [revision]")
+)
+
+// Options define the options for build command.
+type Options struct {
+	// Trigger define the function that start the build for Change.
+	// This field is required.
+	Trigger func(change *Change) error
+
+	// RepoBaseURL define the URL prefix of repository, the repository
+	// URL is RepoBaseURL followed by the repo name.
+	// This field is required.
+	RepoBaseURL string
+}
+
+// Change define the build request, compatible with Buildbot change.
+type Change struct {
+	Author     string
+	Branch     string
+	Category   string
+	Comments   string
+	Project    string
+	Repository string
+	Revision   string
+}
+
+// newChange create new Change from command arguments `args`.
+func newChange(opts *Options, args []string) (change *Change, err error) {
+	if len(args) == 0 {
+		return nil, ErrMissingRepo
+	}
+
+	change = &Change{
+		Branch:   defBranch,
+		Category: defCategory,
+		Comments: defComments,
+	}
+
+	repo := args[0]
+	if len(args) >= 2 {
+		change.Branch = args[1]
+	}
+	if len(args) >= 3 {
+		change.Revision = args[2]
+	}
+
+	change.Project = fmt.Sprintf("mattermost_build_%s_%s", repo,
+		change.Branch)
+	change.Repository = opts.RepoBaseURL + repo
+
+	return change, nil
+}
+
+// Command create the slash.HandlerFunc for "/build" command.
+// The Change Author is set to the user name that run the command.
+func Command(opts Options) (handler slash.HandlerFunc, err error) {
+	if len(opts.RepoBaseURL) == 0 {
+		return nil, ErrRepoBaseURLEmpty
+	}
+	if opts.Trigger == nil {
+		return nil, ErrTriggerNil
+	}
+
+	handler = func(req *slash.Request) (res *slash.Response, err error) {
+		var change *Change
+
+		change, err = newChange(&opts, req.Args)
+		if err != nil {
+			return nil, err
+		}
+		change.Author = req.UserName
+
+		err = opts.Trigger(change)
+		if err != nil {
+			return nil, fmt.Errorf("/build %s: %w", change.Project, err)
+		}
+
+		text := fmt.Sprintf("@%s start building %s branch %s",
+			req.UserName, change.Repository, change.Branch)
+		if len(change.Revision) > 0 {
+			text += " revision " + change.Revision
+		}
+
+		return slash.InChannel(text), nil
+	}
+
+	return handler, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"errors"
+	"reflect"
+	"runtime/debug"
+	"testing"
+
+	"github.com/shuLhan/mattermost-integration/slash"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	_, err := Command(Options{})
+	assert(t, ErrRepoBaseURLEmpty, err, true)
+
+	_, err = Command(Options{RepoBaseURL: "https://git.example.com/"})
+	assert(t, ErrTriggerNil, err, true)
+
+	var gotChange *Change
+
+	cmd, err := Command(Options{
+		RepoBaseURL: "https://git.example.com/",
+		Trigger: func(change *Change) error {
+			if change.Branch == "broken" {
+				return errors.New("build failed")
+			}
+			gotChange = change
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expChange *Change
+		expRes    *slash.Response
+		desc      string
+		expErr    string
+		args      []string
+	}{
+		{
+			desc:   "With empty args",
+			expErr: ErrMissingRepo.Error(),
+		},
+		{
+			desc: "With repo only",
+			args: []string{"app"},
+			expChange: &Change{
+				Author:     "alice",
+				Branch:     "master",
+				Category:   "mattermost",
+				Comments:   "Build from mattermost",
+				Project:    "mattermost_build_app_master",
+				Repository: "https://git.example.com/app",
+			},
+			expRes: slash.InChannel("@alice start building https://git.example.com/app branch master"),
+		},
+		{
+			desc: "With branch and revision",
+			args: []string{"app", "dev", "abc123"},
+			expChange: &Change{
+				Author:     "alice",
+				Branch:     "dev",
+				Category:   "mattermost",
+				Comments:   "Build from mattermost",
+				Project:    "mattermost_build_app_dev",
+				Repository: "https://git.example.com/app",
+				Revision:   "abc123",
+			},
+			expRes: slash.InChannel("@alice start building https://git.example.com/app branch dev revision abc123"),
+		},
+		{
+			desc:   "With trigger error",
+			args:   []string{"app", "broken"},
+			expErr: "/build mattermost_build_app_broken: build failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		gotChange = nil
+
+		res, err := cmd(&slash.Request{
+			UserName: "alice",
+			Args:     test.args,
+		})
+		if err != nil {
+			assert(t, test.expErr, err.Error(), true)
+			continue
+		}
+
+		assert(t, test.expChange, gotChange, true)
+		assert(t, test.expRes, res, true)
+	}
+}
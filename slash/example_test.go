@@ -0,0 +1,44 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slash_test
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/shuLhan/mattermost-integration/slash"
+	"github.com/shuLhan/mattermost-integration/slash/build"
+)
+
+func ExampleServer() {
+	srv, err := slash.NewServer(slash.Options{
+		Tokens:   []string{"token-of-build", "token-of-deploy"},
+		Channels: []string{"ops"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cmdBuild, err := build.Command(build.Options{
+		RepoBaseURL: "https://git.example.com/",
+		Trigger: func(change *build.Change) error {
+			fmt.Println("build", change.Repository, change.Branch)
+			return nil
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv.Handle("/build", cmdBuild)
+
+	srv.Handle("/deploy rollback", func(req *slash.Request) (*slash.Response, error) {
+		// req.SubCommand is "rollback", req.Args contains the rest of
+		// words after "rollback".
+		return slash.InChannel("Rollback " + req.Args[0]), nil
+	})
+
+	http.Handle("/mattermost/slash", srv)
+}
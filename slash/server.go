@@ -0,0 +1,211 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slash
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrTokenEmpty define an error when the Options does not contains any
+// token.
+var ErrTokenEmpty = errors.New("empty token")
+
+// HandlerFunc define the function that handle the slash command.
+// If the error is not nil, the error is send as ephemeral response.
+// If the response is nil, empty response is send to Mattermost.
+type HandlerFunc func(req *Request) (res *Response, err error)
+
+// Options define the options for Server.
+//
+// The team, channel, and user allow-list are matched against its ID or
+// name.
+// The empty allow-list means all teams, channels, or users are allowed.
+type Options struct {
+	// Tokens define list of valid token.
+	// Each slash command in Mattermost has its own token, so one Server
+	// can serve multiple commands.
+	// This field is required.
+	Tokens []string
+
+	// Teams define list of team ID or domain that allowed to run the
+	// command.
+	Teams []string
+
+	// Channels define list of channel ID or name that allowed to run
+	// the command.
+	Channels []string
+
+	// Users define list of user ID or name that allowed to run the
+	// command.
+	Users []string
+}
+
+// validate the options.
+func (opts *Options) validate() error {
+	for _, token := range opts.Tokens {
+		if len(token) > 0 {
+			return nil
+		}
+	}
+	return ErrTokenEmpty
+}
+
+// isValidToken return true if the `token` is one of the Tokens.
+func (opts *Options) isValidToken(token string) bool {
+	var valid bool
+
+	for _, exp := range opts.Tokens {
+		if len(exp) == 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(exp), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// isAllowed return true if the request is from allowed team, channel, and
+// user.
+func (opts *Options) isAllowed(req *Request) bool {
+	return isAllowed(opts.Teams, req.TeamID, req.TeamDomain) &&
+		isAllowed(opts.Channels, req.ChannelID, req.ChannelName) &&
+		isAllowed(opts.Users, req.UserID, req.UserName)
+}
+
+// isAllowed return true if the `list` is empty or contains `id` or
+// `name`.
+func isAllowed(list []string, id, name string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == id || v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Server define the http.Handler that validate and dispatch the slash
+// command to the registered HandlerFunc.
+type Server struct {
+	handlers map[string]HandlerFunc
+	opts     Options
+	locker   sync.RWMutex
+}
+
+// NewServer create new Server using the Options.
+func NewServer(opts Options) (srv *Server, err error) {
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	srv = &Server{
+		opts:     opts,
+		handlers: make(map[string]HandlerFunc),
+	}
+	return srv, nil
+}
+
+// Handle register the HandlerFunc for `command`.
+//
+// The command can contains the sub-command separated by space, for example
+// "/deploy rollback".
+// The request is dispatched to the handler with sub-command if the first
+// word in request text match the sub-command, otherwise to the handler
+// without sub-command.
+// Registering the same command replace the previous handler.
+func (srv *Server) Handle(command string, handler HandlerFunc) {
+	command = strings.Join(strings.Fields(command), " ")
+
+	srv.locker.Lock()
+	srv.handlers[command] = handler
+	srv.locker.Unlock()
+}
+
+// ServeHTTP validate, parse, and dispatch the slash command request.
+//
+// It will response with status 405 if the method is not POST, 400 if the
+// request cannot be parsed, and 401 if the token is invalid.
+// The request from team, channel, or user that is not allowed, the unknown
+// command, and error from handler are send as ephemeral response with
+// status 200, since Mattermost does not display the body of non-200
+// response.
+func (srv *Server) ServeHTTP(res http.ResponseWriter, httpReq *http.Request) {
+	if httpReq.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		http.Error(res, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := ParseRequest(httpReq)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !srv.opts.isValidToken(req.Token) {
+		http.Error(res, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	if !srv.opts.isAllowed(req) {
+		writeResponse(res, Ephemeral("You are not allowed to run "+
+			req.Command+" in this channel."))
+		return
+	}
+
+	handler := srv.handler(req)
+	if handler == nil {
+		writeResponse(res, Ephemeral("Unknown command: "+
+			strings.TrimSpace(req.Command+" "+req.Text)))
+		return
+	}
+
+	resCmd, err := handler(req)
+	if err != nil {
+		resCmd = Ephemeral(err.Error())
+	}
+	if resCmd == nil {
+		resCmd = &Response{}
+	}
+
+	writeResponse(res, resCmd)
+}
+
+// handler return the HandlerFunc for request, and set the SubCommand and
+// Args in request.
+func (srv *Server) handler(req *Request) (handler HandlerFunc) {
+	srv.locker.RLock()
+	defer srv.locker.RUnlock()
+
+	if len(req.Args) > 0 {
+		handler = srv.handlers[req.Command+" "+req.Args[0]]
+		if handler != nil {
+			req.SubCommand = req.Args[0]
+			req.Args = req.Args[1:]
+			return handler
+		}
+	}
+	return srv.handlers[req.Command]
+}
+
+// writeResponse write the Response as JSON.
+func writeResponse(res http.ResponseWriter, resCmd *Response) {
+	body, err := json.Marshal(resCmd)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(body)
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slash
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	_, err := NewServer(Options{})
+	assert(t, ErrTokenEmpty, err, true)
+
+	_, err = NewServer(Options{Tokens: []string{""}})
+	assert(t, ErrTokenEmpty, err, true)
+}
+
+func TestServerServeHTTP(t *testing.T) {
+	srv, err := NewServer(Options{
+		Tokens:   []string{"t1", "t2"},
+		Teams:    []string{"dev"},
+		Channels: []string{"ops"},
+		Users:    []string{"alice", "u2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotReq *Request
+
+	srv.Handle("/deploy", func(req *Request) (*Response, error) {
+		gotReq = req
+		return InChannel("deploying " + strings.Join(req.Args, ",")), nil
+	})
+	srv.Handle("/deploy  rollback", func(req *Request) (*Response, error) {
+		gotReq = req
+		return Ephemeral("rollback " + strings.Join(req.Args, ",")), nil
+	})
+	srv.Handle("/fail", func(req *Request) (*Response, error) {
+		return nil, errors.New("command failed")
+	})
+
+	allowed := url.Values{
+		"token":        {"t2"},
+		"team_domain":  {"dev"},
+		"channel_name": {"ops"},
+		"user_id":      {"u2"},
+		"user_name":    {"bob"},
+	}
+
+	newForm := func(command, text string) url.Values {
+		form := url.Values{}
+		for k, v := range allowed {
+			form[k] = v
+		}
+		form.Set("command", command)
+		form.Set("text", text)
+		return form
+	}
+
+	tests := []struct {
+		form          url.Values
+		desc          string
+		method        string
+		expBody       string
+		expSubCommand string
+		expArgs       []string
+		expStatus     int
+	}{
+		{
+			desc:      "With GET method",
+			method:    http.MethodGet,
+			expStatus: http.StatusMethodNotAllowed,
+			expBody:   "Method Not Allowed\n",
+		},
+		{
+			desc: "With invalid token",
+			form: url.Values{
+				"token":   {"xx"},
+				"command": {"/deploy"},
+			},
+			expStatus: http.StatusUnauthorized,
+			expBody:   "invalid token\n",
+		},
+		{
+			desc: "With channel not allowed",
+			form: url.Values{
+				"token":        {"t1"},
+				"command":      {"/deploy"},
+				"team_domain":  {"dev"},
+				"channel_name": {"town-square"},
+				"user_name":    {"alice"},
+			},
+			expStatus: http.StatusOK,
+			expBody:   `{"response_type":"ephemeral","text":"You are not allowed to run /deploy in this channel."}`,
+		},
+		{
+			desc:      "With unknown command",
+			form:      newForm("/unknown", "a b"),
+			expStatus: http.StatusOK,
+			expBody:   `{"response_type":"ephemeral","text":"Unknown command: /unknown a b"}`,
+		},
+		{
+			desc:      "With handler error",
+			form:      newForm("/fail", ""),
+			expStatus: http.StatusOK,
+			expBody:   `{"response_type":"ephemeral","text":"command failed"}`,
+		},
+		{
+			desc:      "With command",
+			form:      newForm("/deploy", " app  v1.0.0 "),
+			expStatus: http.StatusOK,
+			expBody:   `{"response_type":"in_channel","text":"deploying app,v1.0.0"}`,
+			expArgs:   []string{"app", "v1.0.0"},
+		},
+		{
+			desc:          "With sub-command",
+			form:          newForm("/deploy", "rollback app"),
+			expStatus:     http.StatusOK,
+			expBody:       `{"response_type":"ephemeral","text":"rollback app"}`,
+			expSubCommand: "rollback",
+			expArgs:       []string{"app"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		method := test.method
+		if len(method) == 0 {
+			method = http.MethodPost
+		}
+
+		httpReq := httptest.NewRequest(method, "/",
+			strings.NewReader(test.form.Encode()))
+		httpReq.Header.Set("Content-Type",
+			"application/x-www-form-urlencoded")
+
+		rec := httptest.NewRecorder()
+		gotReq = nil
+
+		srv.ServeHTTP(rec, httpReq)
+
+		assert(t, test.expStatus, rec.Code, true)
+		assert(t, test.expBody, rec.Body.String(), true)
+
+		if gotReq != nil {
+			assert(t, test.expSubCommand, gotReq.SubCommand, true)
+			assert(t, test.expArgs, gotReq.Args, true)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slash contains the HTTP server for Mattermost custom slash
+// commands [1].
+//
+// The Server validate the request token and the allowed teams, channels,
+// and users; parse the form payload into Request; and dispatch it to the
+// HandlerFunc registered with the command and optional sub-command.
+//
+// # Example
+//
+//	srv, err := slash.NewServer(slash.Options{
+//		Tokens:   []string{"xxx"},
+//		Channels: []string{"ops"},
+//	})
+//	...
+//	cmdBuild, err := build.Command(build.Options{...})
+//	...
+//	srv.Handle("/build", cmdBuild)
+//	srv.Handle("/deploy rollback", rollbackHandler)
+//
+//	http.Handle("/mattermost/slash", srv)
+//
+// See the Server example for the complete code.
+//
+// [1] https://developers.mattermost.com/integrate/slash-commands/custom/
+package slash
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// List of response type.
+const (
+	// ResponseEphemeral define the response that only visible to the
+	// user that run the command.
+	ResponseEphemeral = "ephemeral"
+
+	// ResponseInChannel define the response that visible to all users
+	// in the channel.
+	ResponseInChannel = "in_channel"
+)
+
+// maxRequestSize define the maximum size of request body.
+const maxRequestSize = 1 << 20
+
+// Request define the payload of slash command that Mattermost send.
+type Request struct {
+	ChannelID   string
+	ChannelName string
+	Command     string
+	ResponseURL string
+	TeamDomain  string
+	TeamID      string
+	Text        string
+	Token       string
+	TriggerID   string
+	UserID      string
+	UserName    string
+
+	// SubCommand contains the sub-command of registered handler, if
+	// the handler is registered with sub-command.
+	SubCommand string
+
+	// Args contains the words in Text, excluding the SubCommand.
+	Args []string
+}
+
+// ParseRequest parse the form payload in HTTP request into Request.
+func ParseRequest(httpReq *http.Request) (req *Request, err error) {
+	var logp = "ParseRequest"
+
+	httpReq.Body = http.MaxBytesReader(nil, httpReq.Body, maxRequestSize)
+
+	err = httpReq.ParseForm()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logp, err)
+	}
+
+	var form = httpReq.Form
+
+	req = &Request{
+		ChannelID:   form.Get("channel_id"),
+		ChannelName: form.Get("channel_name"),
+		Command:     form.Get("command"),
+		ResponseURL: form.Get("response_url"),
+		TeamDomain:  form.Get("team_domain"),
+		TeamID:      form.Get("team_id"),
+		Text:        form.Get("text"),
+		Token:       form.Get("token"),
+		TriggerID:   form.Get("trigger_id"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+	}
+	req.Args = strings.Fields(req.Text)
+
+	return req, nil
+}
+
+// Response define the response of slash command.
+type Response struct {
+	// ResponseType define the visibility of response, either
+	// ResponseEphemeral or ResponseInChannel.
+	// If its empty, Mattermost use ResponseEphemeral.
+	ResponseType string `json:"response_type,omitempty"`
+
+	// Text define the message of response, in Markdown format.
+	Text string `json:"text"`
+
+	// Username define the user name that send the response.
+	Username string `json:"username,omitempty"`
+
+	// IconURL define the URL of profile picture of the response
+	// sender.
+	IconURL string `json:"icon_url,omitempty"`
+
+	// GotoLocation define the URL that Mattermost redirect the user
+	// to after the command executed.
+	GotoLocation string `json:"goto_location,omitempty"`
+}
+
+// Ephemeral create new Response that only visible to the user that run
+// the command.
+func Ephemeral(text string) *Response {
+	return &Response{
+		ResponseType: ResponseEphemeral,
+		Text:         text,
+	}
+}
+
+// InChannel create new Response that visible to all users in the channel.
+func InChannel(text string) *Response {
+	return &Response{
+		ResponseType: ResponseInChannel,
+		Text:         text,
+	}
+}
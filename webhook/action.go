@@ -0,0 +1,194 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// List of action type.
+const (
+	ActionTypeButton = "button"
+	ActionTypeSelect = "select"
+)
+
+// List of data source for action with type select.
+const (
+	ActionDataSourceChannels = "channels"
+	ActionDataSourceUsers    = "users"
+)
+
+// Action define interactive button or select menu in message attachment
+// [1].
+//
+// When user click the button or select an option, Mattermost send HTTP POST
+// request to the Integration URL with the Integration Context.
+//
+// [1] https://developers.mattermost.com/integrate/plugins/interactive-messages/
+type Action struct {
+	// Integration define the URL and context that will be send when
+	// the action triggered.
+	Integration *Integration
+
+	// ID define the unique identifier of action in attachment.
+	// It must contains only letters and numbers.
+	ID string
+
+	// Name define the text of button or placeholder of select menu.
+	Name string
+
+	// Type define the type of action, either ActionTypeButton or
+	// ActionTypeSelect.
+	// If its empty, Mattermost render the action as button.
+	Type string
+
+	// Style define the style of button, for example "default",
+	// "primary", "success", "good", "warning", "danger", or hex color.
+	Style string
+
+	// DataSource define the dynamic options for select menu, either
+	// ActionDataSourceChannels or ActionDataSourceUsers.
+	DataSource string
+
+	// DefaultOption define the value of default option for select menu.
+	DefaultOption string
+
+	// Options define list of static options for select menu.
+	Options []ActionOption
+}
+
+// ActionOption define single option in select menu.
+type ActionOption struct {
+	Text  string
+	Value string
+}
+
+// List of reserved keys in Integration Context, shared by the action
+// handler in package action and the hooks that create the actions.
+const (
+	// ContextActionID define the key for action ID, used to dispatch
+	// the request to the callback.
+	ContextActionID = "action_id"
+
+	// ContextFingerprint define the key for fingerprint of log entry
+	// that trigger the message.
+	ContextFingerprint = "fingerprint"
+
+	// ContextMessage define the key for the message of log entry that
+	// trigger the message.
+	ContextMessage = "message"
+
+	// ContextSelectedOption define the key where Mattermost store the
+	// value of selected option in select menu.
+	ContextSelectedOption = "selected_option"
+
+	// ContextSecret define the key for shared secret that authenticate
+	// the request.
+	ContextSecret = "secret"
+)
+
+// Integration define the request that Mattermost send when the Action is
+// triggered.
+type Integration struct {
+	// Context define the data that will be send back to URL.
+	Context map[string]interface{}
+
+	// URL define the endpoint that receive the action request.
+	URL string
+}
+
+// MarshalJSON will convert Action into JSON.
+// The empty field is skipped.
+func (action Action) MarshalJSON() (out []byte, err error) {
+	var (
+		buf bytes.Buffer
+		raw []byte
+	)
+
+	_ = buf.WriteByte('{')
+
+	if len(action.DataSource) > 0 {
+		_ = bufWriteKV(&buf, `"data_source"`, []byte(action.DataSource),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(action.DefaultOption) > 0 {
+		_ = bufWriteKV(&buf, `"default_option"`,
+			[]byte(action.DefaultOption), ':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(action.ID) > 0 {
+		_ = bufWriteKV(&buf, `"id"`, []byte(action.ID), ':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if action.Integration != nil {
+		raw, err = action.Integration.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		_, _ = buf.WriteString(`"integration":`)
+		_, _ = buf.Write(raw)
+		_ = buf.WriteByte(',')
+	}
+	if len(action.Name) > 0 {
+		_ = bufWriteKV(&buf, `"name"`, []byte(action.Name), ':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(action.Options) > 0 {
+		_, _ = buf.WriteString(`"options":[`)
+		for x, opt := range action.Options {
+			if x > 0 {
+				_ = buf.WriteByte(',')
+			}
+			_ = buf.WriteByte('{')
+			_ = bufWriteKV(&buf, `"text"`, []byte(opt.Text), ':', '"', '"')
+			_ = buf.WriteByte(',')
+			_ = bufWriteKV(&buf, `"value"`, []byte(opt.Value), ':', '"', '"')
+			_ = buf.WriteByte('}')
+		}
+		_, _ = buf.WriteString(`],`)
+	}
+	if len(action.Style) > 0 {
+		_ = bufWriteKV(&buf, `"style"`, []byte(action.Style), ':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(action.Type) > 0 {
+		_ = bufWriteKV(&buf, `"type"`, []byte(action.Type), ':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+
+	out = bytes.TrimSuffix(buf.Bytes(), []byte(","))
+	out = append(out, '}')
+
+	return out, nil
+}
+
+// MarshalJSON will convert Integration into JSON.
+// The Context is converted using encoding/json, since its value can be any
+// type.
+func (integration Integration) MarshalJSON() (out []byte, err error) {
+	var (
+		buf bytes.Buffer
+		raw []byte
+	)
+
+	_ = buf.WriteByte('{')
+
+	if len(integration.Context) > 0 {
+		raw, err = json.Marshal(integration.Context)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = buf.WriteString(`"context":`)
+		_, _ = buf.Write(raw)
+		_ = buf.WriteByte(',')
+	}
+
+	_ = bufWriteKV(&buf, `"url"`, []byte(integration.URL), ':', '"', '"')
+	_ = buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestActionMarshalJSON(t *testing.T) {
+	tests := []struct {
+		desc string
+		exp  string
+		in   Action
+	}{
+		{
+			desc: "With empty action",
+			exp:  `{}`,
+		},
+		{
+			desc: "With button",
+			in: Action{
+				ID:    "ack",
+				Name:  "Acknowledge",
+				Type:  ActionTypeButton,
+				Style: "primary",
+				Integration: &Integration{
+					URL: "https://example.com/actions/ack",
+					Context: map[string]interface{}{
+						"action": "ack",
+						"id":     42,
+					},
+				},
+			},
+			exp: `{"id":"ack","integration":{"context":{"action":"ack","id":42},"url":"https://example.com/actions/ack"},"name":"Acknowledge","style":"primary","type":"button"}`,
+		},
+		{
+			desc: "With select options",
+			in: Action{
+				ID:            "silence",
+				Name:          "Silence",
+				Type:          ActionTypeSelect,
+				DefaultOption: "1h",
+				Options: []ActionOption{{
+					Text:  "1 hour",
+					Value: "1h",
+				}, {
+					Text:  "1 day",
+					Value: "24h",
+				}},
+				Integration: &Integration{
+					URL: "https://example.com/actions/silence",
+				},
+			},
+			exp: `{"default_option":"1h","id":"silence","integration":{"url":"https://example.com/actions/silence"},"name":"Silence","options":[{"text":"1 hour","value":"1h"},{"text":"1 day","value":"24h"}],"type":"select"}`,
+		},
+		{
+			desc: "With select data source",
+			in: Action{
+				ID:         "assign",
+				Name:       "Assign to",
+				Type:       ActionTypeSelect,
+				DataSource: ActionDataSourceUsers,
+			},
+			exp: `{"data_source":"users","id":"assign","name":"Assign to","type":"select"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got, err := test.in.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, string(got), true)
+		assert(t, true, json.Valid(got), true)
+	}
+}
+
+func TestIntegrationMarshalJSON(t *testing.T) {
+	integration := Integration{
+		URL: "https://example.com",
+		Context: map[string]interface{}{
+			"invalid": make(chan int),
+		},
+	}
+
+	_, err := integration.MarshalJSON()
+	if err == nil {
+		t.Fatal("expecting error on invalid context")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+// Attachment define Mattermost message attachment [1].
+//
+// [1] https://docs.mattermost.com/developer/message-attachments.html
+type Attachment struct {
+	// Timestamp define the time displayed in the attachment footer.
+	Timestamp time.Time
+
+	AuthorIcon string
+	AuthorLink string
+	AuthorName string
+	Color      string
+	Fallback   string
+	Footer     string
+	FooterIcon string
+	ImageURL   string
+	Pretext    string
+	Text       string
+	ThumbURL   string
+	Title      string
+	TitleLink  string
+
+	Fields Fields
+
+	// Actions define list of interactive buttons or select menus in
+	// attachment.
+	Actions []Action
+}
+
+func (attc Attachment) marshalAuthor(buf *bytes.Buffer) {
+	if len(attc.AuthorIcon) > 0 {
+		_ = bufWriteKV(buf, `"author_icon"`, []byte(attc.AuthorIcon),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.AuthorLink) > 0 {
+		_ = bufWriteKV(buf, `"author_link"`, []byte(attc.AuthorLink),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.AuthorName) > 0 {
+		_ = bufWriteKV(buf, `"author_name"`, []byte(attc.AuthorName),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+}
+
+// MarshalJSON will convert Attachment `attc` to JSON.
+func (attc Attachment) MarshalJSON() (out []byte, err error) {
+	var buf bytes.Buffer
+	var bFields []byte
+
+	_ = buf.WriteByte('{')
+
+	if len(attc.Actions) > 0 {
+		_, _ = buf.WriteString(`"actions":[`)
+		for x, action := range attc.Actions {
+			var bAction []byte
+
+			bAction, err = action.MarshalJSON()
+			if err != nil {
+				return
+			}
+			if x > 0 {
+				_ = buf.WriteByte(',')
+			}
+			_, _ = buf.Write(bAction)
+		}
+		_, _ = buf.WriteString(`],`)
+	}
+
+	attc.marshalAuthor(&buf)
+
+	if len(attc.Color) > 0 {
+		_ = bufWriteKV(&buf, `"color"`, []byte(attc.Color),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.Fallback) > 0 {
+		_ = bufWriteKV(&buf, `"fallback"`, []byte(attc.Fallback),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.Fields) > 0 {
+		bFields, err = attc.Fields.MarshalJSON()
+		if err != nil {
+			return
+		}
+
+		_, _ = buf.WriteString(`"fields":`)
+		_, _ = buf.Write(bFields)
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.Footer) > 0 {
+		_ = bufWriteKV(&buf, `"footer"`, []byte(attc.Footer),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.FooterIcon) > 0 {
+		_ = bufWriteKV(&buf, `"footer_icon"`, []byte(attc.FooterIcon),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.ImageURL) > 0 {
+		_ = bufWriteKV(&buf, `"image_url"`, []byte(attc.ImageURL),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.Pretext) > 0 {
+		_ = bufWriteKV(&buf, `"pretext"`, []byte(attc.Pretext),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+
+	}
+	if len(attc.Text) > 0 {
+		_ = bufWriteKV(&buf, `"text"`, []byte(attc.Text),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.ThumbURL) > 0 {
+		_ = bufWriteKV(&buf, `"thumb_url"`, []byte(attc.ThumbURL),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.Title) > 0 {
+		_ = bufWriteKV(&buf, `"title"`, []byte(attc.Title),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(attc.TitleLink) > 0 {
+		_ = bufWriteKV(&buf, `"title_link"`, []byte(attc.TitleLink),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if !attc.Timestamp.IsZero() {
+		_ = bufWriteKV(&buf, `"ts"`,
+			[]byte(strconv.FormatInt(attc.Timestamp.Unix(), 10)),
+			':', 0, 0)
+		_ = buf.WriteByte(',')
+	}
+
+	out = buf.Bytes()
+
+	out = bytes.TrimSuffix(out, []byte(","))
+	out = append(out, []byte("}")...)
+
+	return
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAttachmentMarshalJSON(t *testing.T) {
+	tests := []struct {
+		desc string
+		exp  string
+		in   Attachment
+	}{
+		{
+			desc: "With empty fields",
+			in:   Attachment{},
+			exp:  `{}`,
+		},
+		{
+			desc: "With empty author_icon",
+			in: Attachment{
+				AuthorIcon: "",
+				AuthorLink: "authorlink",
+			},
+			exp: `{"author_link":"authorlink"}`,
+		},
+		{
+			desc: "With fields",
+			in: Attachment{
+				AuthorIcon: "",
+				AuthorLink: "authorlink",
+				Fields: Fields{
+					{
+						Short: false,
+						Title: "t1",
+						Value: "v1",
+					},
+					{
+						Short: true,
+					},
+					{
+						Short: true,
+						Title: "t3",
+						Value: "v3",
+					},
+				},
+			},
+			exp: `{"author_link":"authorlink","fields":[{"short":false,"title":"t1","value":"v1"},{"short":true,"title":"t3","value":"v3"}]}`,
+		},
+		{
+			desc: "With footer, thumb, and timestamp",
+			in: Attachment{
+				Footer:     "my-app",
+				FooterIcon: "https://example.com/footer.png",
+				Text:       "text",
+				ThumbURL:   "https://example.com/thumb.png",
+				Timestamp:  time.Unix(1676714400, 0),
+			},
+			exp: `{"footer":"my-app","footer_icon":"https://example.com/footer.png","text":"text","thumb_url":"https://example.com/thumb.png","ts":1676714400}`,
+		},
+		{
+			desc: "With actions",
+			in: Attachment{
+				Text: "text",
+				Actions: []Action{{
+					ID:   "ack",
+					Name: "Acknowledge",
+					Integration: &Integration{
+						URL: "https://example.com/ack",
+					},
+				}, {
+					ID:   "silence",
+					Name: "Silence for 1h",
+				}},
+			},
+			exp: `{"actions":[{"id":"ack","integration":{"url":"https://example.com/ack"},"name":"Acknowledge"},{"id":"silence","name":"Silence for 1h"}],"text":"text"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got, err := test.in.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, string(got), true)
+	}
+}
+
+func FuzzAttachmentMarshalJSON(f *testing.F) {
+	f.Add("title", "k1", "v1", "k2", "", "text")
+	f.Add("", "", "v1", "k2", "v2", "")
+	f.Add("\"quoted\"", "k\n1", "\xff", "", "", "\u2028")
+
+	f.Fuzz(func(t *testing.T, title, k1, v1, k2, v2, text string) {
+		attc := Attachment{
+			Title: title,
+			Text:  text,
+			Fields: Fields{
+				{Title: k1, Value: v1},
+				{Title: k2, Value: v2, Short: true},
+				{Title: k1, Value: v2},
+			},
+		}
+
+		got, err := attc.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out struct {
+			Title  string `json:"title"`
+			Text   string `json:"text"`
+			Fields []struct {
+				Title string `json:"title"`
+				Value string `json:"value"`
+				Short bool   `json:"short"`
+			} `json:"fields"`
+		}
+
+		err = json.Unmarshal(got, &out)
+		if err != nil {
+			t.Fatalf("%q: %s", got, err)
+		}
+
+		var expFields int
+		for _, field := range attc.Fields {
+			if len(field.Title) > 0 && len(field.Value) > 0 {
+				expFields++
+			}
+		}
+		if len(out.Fields) != expFields {
+			t.Fatalf("%q: expecting %d fields, got %d", got,
+				expFields, len(out.Fields))
+		}
+		if out.Text != string([]rune(text)) {
+			t.Fatalf("expecting text %q, got %q", text, out.Text)
+		}
+	})
+}
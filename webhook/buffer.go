@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+const _hex = "0123456789abcdef"
+
+// bufWriteKV write the key `k`, separator `sep`, and value `v` into
+// buffer.
+// If `l` or `r` is not zero, the value is wrapped with `l` and `r`.
+// The value is escaped as JSON string, while the key is written as is.
+func bufWriteKV(buf *bytes.Buffer, k string, v []byte, sep, l, r byte) (
+	err error,
+) {
+	_, err = buf.WriteString(k)
+	if err != nil {
+		return
+	}
+	err = buf.WriteByte(sep)
+	if err != nil {
+		return
+	}
+	if l > 0 {
+		err = buf.WriteByte(l)
+		if err != nil {
+			return
+		}
+	}
+
+	err = bufWriteEscape(buf, v)
+	if err != nil {
+		return
+	}
+
+	if r > 0 {
+		err = buf.WriteByte(r)
+	}
+
+	return
+}
+
+// bufWriteEscape write `v` into buffer as the content of JSON string, as
+// defined in RFC 8259 section 7.
+//
+// The quotation mark, reverse solidus, and control characters are escaped.
+// The U+2028 and U+2029 are escaped too, so the output is safe to be
+// embedded in JavaScript.
+// Each byte of invalid UTF-8 sequence is replaced with U+FFFD.
+func bufWriteEscape(buf *bytes.Buffer, v []byte) (err error) {
+	var start int
+
+	for x := 0; x < len(v); {
+		c := v[x]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				x++
+				continue
+			}
+
+			_, err = buf.Write(v[start:x])
+			if err != nil {
+				return
+			}
+
+			switch c {
+			case '"', '\\':
+				_, err = buf.Write([]byte{'\\', c})
+			case '\n':
+				_, err = buf.WriteString(`\n`)
+			case '\r':
+				_, err = buf.WriteString(`\r`)
+			case '\t':
+				_, err = buf.WriteString(`\t`)
+			case '\b':
+				_, err = buf.WriteString(`\b`)
+			case '\f':
+				_, err = buf.WriteString(`\f`)
+			default:
+				_, err = buf.Write([]byte{'\\', 'u', '0', '0',
+					_hex[c>>4], _hex[c&0xF]})
+			}
+			if err != nil {
+				return
+			}
+
+			x++
+			start = x
+			continue
+		}
+
+		r, size := utf8.DecodeRune(v[x:])
+		if r == utf8.RuneError && size == 1 {
+			_, err = buf.Write(v[start:x])
+			if err != nil {
+				return
+			}
+			_, err = buf.WriteString(`\ufffd`)
+			if err != nil {
+				return
+			}
+			x += size
+			start = x
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			_, err = buf.Write(v[start:x])
+			if err != nil {
+				return
+			}
+			_, err = buf.WriteString(`\u202`)
+			if err != nil {
+				return
+			}
+			err = buf.WriteByte(_hex[r&0xF])
+			if err != nil {
+				return
+			}
+			x += size
+			start = x
+			continue
+		}
+		x += size
+	}
+
+	_, err = buf.Write(v[start:])
+
+	return
+}
+
+// jsonEscape return the string `s` escaped as the content of JSON string.
+func jsonEscape(s string) string {
+	var buf bytes.Buffer
+
+	_ = bufWriteEscape(&buf, []byte(s))
+
+	return buf.String()
+}
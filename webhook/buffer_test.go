@@ -0,0 +1,103 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestBufWriteEscape(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		exp  string
+	}{
+		{
+			desc: "With quote and backslash",
+			in:   `a "b" \c`,
+			exp:  `a \"b\" \\c`,
+		},
+		{
+			desc: "With new line and tab",
+			in:   "line 1\n\tline 2\r\n",
+			exp:  `line 1\n\tline 2\r\n`,
+		},
+		{
+			desc: "With control characters",
+			in:   "\x00\x01\b\f\x1f\x7f",
+			exp:  `\u0000\u0001\b\f\u001f` + "\x7f",
+		},
+		{
+			desc: "With invalid UTF-8",
+			in:   "a\xffb\xe2\x82",
+			exp:  `a\ufffdb\ufffd\ufffd`,
+		},
+		{
+			desc: "With line and paragraph separator",
+			in:   "a\u2028b\u2029c",
+			exp:  `a\u2028b\u2029c`,
+		},
+		{
+			desc: "With valid multi bytes UTF-8",
+			in:   "ペンギン €",
+			exp:  "ペンギン €",
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		var buf bytes.Buffer
+
+		err := bufWriteEscape(&buf, []byte(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, buf.String(), true)
+	}
+}
+
+func FuzzBufWriteEscape(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		`"quoted" \ backslash`,
+		"multi\nline\r\n\tstack trace",
+		"\x00\x1f\x7f",
+		"invalid \xff\xfe UTF-8 \xe2\x82",
+		"\u2028\u2029",
+		"ペンギン",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, in string) {
+		var (
+			buf bytes.Buffer
+			got string
+		)
+
+		_ = buf.WriteByte('"')
+		err := bufWriteEscape(&buf, []byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = buf.WriteByte('"')
+
+		err = json.Unmarshal(buf.Bytes(), &got)
+		if err != nil {
+			t.Fatalf("%q: %s", buf.String(), err)
+		}
+
+		// Converting string to runes replace each byte of invalid
+		// UTF-8 with U+FFFD.
+		exp := string([]rune(in))
+		if exp != got {
+			t.Fatalf("expecting %q, got %q", exp, got)
+		}
+	})
+}
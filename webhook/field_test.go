@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package logrus
+package webhook
 
 import (
 	"testing"
@@ -55,6 +55,14 @@ func TestFieldUnmarshalJSON(t *testing.T) {
 			},
 			exp: `{"short":true,"title":"title","value":"value"}`,
 		},
+		{
+			desc: "With control characters and invalid UTF-8",
+			in: Field{
+				Title: "stack\ttrace",
+				Value: "line 1\nline 2\x00\xff",
+			},
+			exp: `{"short":false,"title":"stack\ttrace","value":"line 1\nline 2\u0000\ufffd"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package logrus
+package webhook
 
 import (
 	"bytes"
@@ -13,25 +13,25 @@ type Fields []Field
 
 // MarshalJSON will convert `field` into a valid JSON. We use manual
 // convertion for gaining speed.
+// The field with empty Title or Value is skipped.
 func (fields Fields) MarshalJSON() (out []byte, err error) {
-	var buf bytes.Buffer
-	sep := false
+	var (
+		buf bytes.Buffer
+		sep bool
+	)
 
 	_ = buf.WriteByte('[')
 
 	for _, field := range fields {
+		fout, _ := field.MarshalJSON()
+		if len(fout) <= 2 {
+			continue
+		}
 		if sep {
 			_ = buf.WriteByte(',')
 		}
-
-		fout, _ := field.MarshalJSON()
-
-		if len(fout) > 2 {
-			_, _ = buf.Write(fout)
-			sep = true
-		} else {
-			sep = false
-		}
+		_, _ = buf.Write(fout)
+		sep = true
 	}
 
 	_ = buf.WriteByte(']')
@@ -2,9 +2,10 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package logrus
+package webhook
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -46,6 +47,34 @@ func TestFieldsUnmarshalJSON(t *testing.T) {
 			},
 			exp: `[{"short":false,"title":"t1","value":"v1"},{"short":false,"title":"t3","value":"v3"}]`,
 		},
+		{
+			desc: "With empty field in first and last",
+			in: Fields{
+				{
+					Title: "t1",
+				},
+				{
+					Title: "t2",
+					Value: "v2",
+				},
+				{
+					Title: "t3",
+					Value: "v3",
+				},
+				{
+					Value: "v4",
+				},
+			},
+			exp: `[{"short":false,"title":"t2","value":"v2"},{"short":false,"title":"t3","value":"v3"}]`,
+		},
+		{
+			desc: "With all fields empty",
+			in: Fields{
+				{Title: "t1"},
+				{Value: "v2"},
+			},
+			exp: `[]`,
+		},
 	}
 
 	for _, test := range tests {
@@ -57,5 +86,6 @@ func TestFieldsUnmarshalJSON(t *testing.T) {
 		}
 
 		assert(t, test.exp, string(got), true)
+		assert(t, true, json.Valid(got), true)
 	}
 }
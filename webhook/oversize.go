@@ -0,0 +1,140 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// minOversizeSize define the minimum of OversizeOptions.MaxSize, so
+	// the part marker and ellipsis always fit.
+	minOversizeSize = 64
+
+	// defOversizeMaxParts define the default maximum number of posts
+	// for OversizeSplit.
+	defOversizeMaxParts = 10
+)
+
+// Ellipsis define the marker appended to the truncated text.
+const Ellipsis = "…"
+
+// OversizePolicy define what to do when the text is larger than the
+// maximum size.
+type OversizePolicy int
+
+// List of oversize policy.
+const (
+	// OversizeTruncate cut the text at the maximum size and append
+	// the Ellipsis.
+	// This is the default policy.
+	OversizeTruncate OversizePolicy = iota
+
+	// OversizeSplit split the text into several posts, each prefixed
+	// with "(n/m)".
+	// The text is split at the line boundary, if possible.
+	OversizeSplit
+)
+
+// OversizeOptions define the options to handle the text that is larger
+// than the maximum post size in Mattermost.
+//
+// In text mode, the size is the number of characters in the rendered
+// text.
+// In attachment mode, the size is checked on each attachment text and
+// field value; the field value is always truncated, while the text is
+// handled based on Policy.
+// If there are more than one attachment, their text are always
+// truncated.
+type OversizeOptions struct {
+	// MaxSize define the maximum number of characters in one post.
+	// Default to MaxPostSize.
+	// The minimum is 64.
+	// Set to negative value to disable it.
+	MaxSize int
+
+	// MaxParts define the maximum number of posts for OversizeSplit.
+	// The last post is truncated if the text need more posts.
+	// Default to 10.
+	MaxParts int
+
+	// Policy define how the oversize text is handled.
+	// Default to OversizeTruncate.
+	Policy OversizePolicy
+}
+
+// Size return the maximum number of characters in one post, after
+// applying the default and minimum value of MaxSize.
+// It will return zero if the oversize handling is disabled.
+func (opts *OversizeOptions) Size() int {
+	switch {
+	case opts.MaxSize < 0:
+		return 0
+	case opts.MaxSize == 0:
+		return MaxPostSize
+	case opts.MaxSize < minOversizeSize:
+		return minOversizeSize
+	}
+	return opts.MaxSize
+}
+
+// maxParts return the MaxParts or its default value.
+func (opts *OversizeOptions) maxParts() int {
+	if opts.MaxParts <= 0 {
+		return defOversizeMaxParts
+	}
+	return opts.MaxParts
+}
+
+// Fit return the text in at most MaxParts parts, where each part has at
+// most MaxSize characters.
+// If the Policy is OversizeTruncate, the text is truncated into single
+// part.
+func (opts *OversizeOptions) Fit(text string) (parts []string) {
+	var (
+		maxSize  = opts.Size()
+		maxParts = opts.maxParts()
+	)
+
+	if maxSize == 0 || utf8.RuneCountInString(text) <= maxSize {
+		return []string{text}
+	}
+	if opts.Policy != OversizeSplit || maxParts == 1 {
+		return []string{TruncateText(text, maxSize)}
+	}
+
+	size := maxSize - MaxPartMarkerSize
+
+	parts = SplitText(text, size)
+	if len(parts) > maxParts {
+		last := maxParts - 1
+		parts[last] = TruncateText(parts[last]+"\n"+parts[last+1], size)
+		parts = parts[:maxParts]
+	}
+	for x, part := range parts {
+		parts[x] = "(" + strconv.Itoa(x+1) + "/" +
+			strconv.Itoa(len(parts)) + ") " + part
+	}
+	return parts
+}
+
+// TruncateText cut the `text` to have at most `max` characters, including
+// the Ellipsis, at the character boundary.
+// If `max` is less than one, the text is returned as is.
+func TruncateText(text string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return text
+	}
+
+	var end, n int
+	for end = range text {
+		if n == max-1 {
+			break
+		}
+		n++
+	}
+	return text[:end] + Ellipsis
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		exp  string
+		max  int
+	}{{
+		desc: "With text less than max",
+		in:   "abc",
+		max:  3,
+		exp:  "abc",
+	}, {
+		desc: "With ASCII",
+		in:   "abcdef",
+		max:  4,
+		exp:  "abc…",
+	}, {
+		desc: "With multibyte",
+		in:   "日本語テキスト",
+		max:  4,
+		exp:  "日本語…",
+	}, {
+		desc: "With zero max",
+		in:   "abcdef",
+		exp:  "abcdef",
+	}}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := TruncateText(test.in, test.max)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func TestOversizeOptionsFit(t *testing.T) {
+	var (
+		line  = strings.Repeat("a", 40)
+		text  = line + "\n" + line + "\n" + line
+		long  = strings.Repeat("a", 200)
+		tests = []struct {
+			desc string
+			in   string
+			exp  []string
+			opts OversizeOptions
+		}{{
+			desc: "With text fit",
+			opts: OversizeOptions{MaxSize: 64},
+			in:   "abc",
+			exp:  []string{"abc"},
+		}, {
+			desc: "With truncate",
+			opts: OversizeOptions{MaxSize: 64},
+			in:   text,
+			exp:  []string{text[:63] + "…"},
+		}, {
+			desc: "With split at line",
+			opts: OversizeOptions{
+				MaxSize: 64,
+				Policy:  OversizeSplit,
+			},
+			in: text,
+			exp: []string{
+				"(1/3) " + line,
+				"(2/3) " + line,
+				"(3/3) " + line,
+			},
+		}, {
+			desc: "With split more than MaxParts",
+			opts: OversizeOptions{
+				MaxSize:  64,
+				MaxParts: 2,
+				Policy:   OversizeSplit,
+			},
+			in: text,
+			exp: []string{
+				"(1/2) " + line,
+				"(2/2) " + line + "\n" + line[:6] + "…",
+			},
+		}, {
+			desc: "With split without line",
+			opts: OversizeOptions{
+				MaxSize: 64,
+				Policy:  OversizeSplit,
+			},
+			in: long,
+			exp: []string{
+				"(1/5) " + long[:48],
+				"(2/5) " + long[:48],
+				"(3/5) " + long[:48],
+				"(4/5) " + long[:48],
+				"(5/5) " + long[:8],
+			},
+		}, {
+			desc: "With disabled",
+			opts: OversizeOptions{MaxSize: -1},
+			in:   long,
+			exp:  []string{long},
+		}}
+	)
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := test.opts.Fit(test.in)
+
+		assert(t, test.exp, got, true)
+	}
+}
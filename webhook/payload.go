@@ -0,0 +1,85 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+)
+
+// Payload define the content of post that will be send to Mattermost
+// incoming webhook [1].
+//
+// [1] https://developers.mattermost.com/integrate/webhooks/incoming/
+type Payload struct {
+	// Channel define the channel name where the post is send.
+	// If its empty, the post is send to the default channel defined in
+	// incoming webhook setting.
+	Channel string
+
+	// Username define the user name that send the post.
+	Username string
+
+	// IconURL define the URL of profile picture of the sender.
+	IconURL string
+
+	// Text define the content of post, in Markdown format.
+	Text string
+
+	// Attachments define list of attachment in post.
+	Attachments []*Attachment
+}
+
+// MarshalJSON will convert Payload into JSON.
+// The empty channel, username, and icon URL are skipped.
+// The text is skipped if the payload has attachments and the text is
+// empty.
+func (payload Payload) MarshalJSON() (out []byte, err error) {
+	var (
+		buf  bytes.Buffer
+		attc []byte
+	)
+
+	_ = buf.WriteByte('{')
+
+	if len(payload.Channel) > 0 {
+		_ = bufWriteKV(&buf, `"channel"`, []byte(payload.Channel),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(payload.Username) > 0 {
+		_ = bufWriteKV(&buf, `"username"`, []byte(payload.Username),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(payload.IconURL) > 0 {
+		_ = bufWriteKV(&buf, `"icon_url"`, []byte(payload.IconURL),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(payload.Text) > 0 || len(payload.Attachments) == 0 {
+		_ = bufWriteKV(&buf, `"text"`, []byte(payload.Text),
+			':', '"', '"')
+		_ = buf.WriteByte(',')
+	}
+	if len(payload.Attachments) > 0 {
+		_, _ = buf.WriteString(`"attachments":[`)
+		for x, a := range payload.Attachments {
+			attc, err = a.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			if x > 0 {
+				_ = buf.WriteByte(',')
+			}
+			_, _ = buf.Write(attc)
+		}
+		_, _ = buf.WriteString(`],`)
+	}
+
+	out = bytes.TrimSuffix(buf.Bytes(), []byte(","))
+	out = append(out, '}')
+
+	return out, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPayloadMarshalJSON(t *testing.T) {
+	tests := []struct {
+		desc string
+		exp  string
+		in   Payload
+	}{
+		{
+			desc: "With empty payload",
+			exp:  `{"text":""}`,
+		},
+		{
+			desc: "With text",
+			in: Payload{
+				Channel:  "town-square",
+				Username: "bot",
+				Text:     "line 1\n\"line 2\"",
+			},
+			exp: `{"channel":"town-square","username":"bot","text":"line 1\n\"line 2\""}`,
+		},
+		{
+			desc: "With attachments only",
+			in: Payload{
+				Attachments: []*Attachment{{
+					Text: "a",
+				}, {
+					Text: "b",
+				}},
+			},
+			exp: `{"attachments":[{"text":"a"},{"text":"b"}]}`,
+		},
+		{
+			desc: "With text and attachments",
+			in: Payload{
+				IconURL: "https://example.com/icon.png",
+				Text:    "summary",
+				Attachments: []*Attachment{{
+					Title: "detail",
+				}},
+			},
+			exp: `{"icon_url":"https://example.com/icon.png","text":"summary","attachments":[{"title":"detail"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got, err := test.in.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, string(got), true)
+		assert(t, true, json.Valid(got), true)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxPostSize define the default maximum number of characters in
+	// one post, follow the default Mattermost MaxPostSize.
+	MaxPostSize = 16383
+
+	// MaxPartMarkerSize define the maximum number of characters of part
+	// marker "(n/m) " that prefix each part of text split by SplitText.
+	MaxPartMarkerSize = 16
+)
+
+// SplitText split the `text` into parts, where each part has at most
+// `max` characters.
+//
+// The text is split at the last new line in each part, or at the last
+// space if the part does not contains new line, or at the character
+// boundary if the part does not contains space.
+// The new line or space where the text is split is removed.
+// The invalid UTF-8 in text is replaced with U+FFFD, so each part is
+// always valid UTF-8.
+//
+// If `max` is less than one, the text is returned as is in single part.
+func SplitText(text string, max int) (parts []string) {
+	text = strings.ToValidUTF8(text, string(utf8.RuneError))
+
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return []string{text}
+	}
+
+	for utf8.RuneCountInString(text) > max {
+		// Find the byte offset of the first `max` characters.
+		var end, n int
+		for end = range text {
+			if n == max {
+				break
+			}
+			n++
+		}
+
+		var (
+			window = text[:end]
+			cut    = strings.LastIndexByte(window, '\n')
+			skip   = 1
+		)
+		if cut <= 0 {
+			cut = strings.LastIndexByte(window, ' ')
+		}
+		if cut <= 0 {
+			cut = end
+			skip = 0
+		}
+
+		parts = append(parts, text[:cut])
+		text = text[cut+skip:]
+	}
+	parts = append(parts, text)
+
+	return parts
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		exp  []string
+		max  int
+	}{
+		{
+			desc: "With text less than max",
+			in:   "line 1\nline 2",
+			max:  20,
+			exp:  []string{"line 1\nline 2"},
+		},
+		{
+			desc: "With zero max",
+			in:   "line 1\nline 2",
+			exp:  []string{"line 1\nline 2"},
+		},
+		{
+			desc: "With new lines",
+			in:   "line 1\nline 2\nline 3",
+			max:  14,
+			exp:  []string{"line 1\nline 2", "line 3"},
+		},
+		{
+			desc: "With spaces",
+			in:   "word1 word2 word3",
+			max:  12,
+			exp:  []string{"word1 word2", "word3"},
+		},
+		{
+			desc: "Without space",
+			in:   "abcdefghij",
+			max:  4,
+			exp:  []string{"abcd", "efgh", "ij"},
+		},
+		{
+			desc: "With multi bytes characters",
+			in:   "ペンギンペンギン",
+			max:  3,
+			exp:  []string{"ペンギ", "ンペン", "ギン"},
+		},
+		{
+			desc: "With invalid UTF-8",
+			in:   "ab\xffcd",
+			max:  3,
+			exp:  []string{"ab\uFFFD", "cd"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := SplitText(test.in, test.max)
+
+		assert(t, test.exp, got, true)
+	}
+}
+
+func FuzzSplitText(f *testing.F) {
+	f.Add("line 1\nline 2\nline 3", 7)
+	f.Add("ペンギン ペンギン", 3)
+	f.Add("ab\xffcd\xe2\x82", 2)
+
+	f.Fuzz(func(t *testing.T, in string, max int) {
+		if max <= 0 || max > 1<<10 {
+			return
+		}
+
+		parts := SplitText(in, max)
+
+		var total int
+		for _, part := range parts {
+			if !utf8.ValidString(part) {
+				t.Fatalf("invalid UTF-8 %q", part)
+			}
+			n := utf8.RuneCountInString(part)
+			if n > max {
+				t.Fatalf("part %q has %d characters, max %d", part, n, max)
+			}
+			total += n
+		}
+
+		// Only the new line or space at the split point are removed.
+		exp := utf8.RuneCountInString(strings.ToValidUTF8(in, "\uFFFD"))
+		if total < exp-len(parts)+1 || total > exp {
+			t.Fatalf("expecting %d characters, got %d", exp, total)
+		}
+	})
+}
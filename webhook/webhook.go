@@ -0,0 +1,214 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webhook contains the client to send post to Mattermost using
+// incoming webhook [1].
+//
+// The package does not depends on any logging framework, it can be used
+// by any program to send text or attachments to Mattermost.
+// The delivery package use this package to send the post queued by the
+// hooks for logrus, slog, zap, and zerolog.
+//
+// # Example
+//
+//	cl := &webhook.Client{
+//		Endpoint: "https://my.mattermost.org/hooks/xxx",
+//		Channel:  "town-square",
+//		Username: "deploy-bot",
+//	}
+//
+//	err := cl.Post(ctx, webhook.Payload{
+//		Text: "Deploy **v1.2.0** has been finished",
+//	})
+//	var serr *webhook.StatusError
+//	if errors.As(err, &serr) {
+//		log.Printf("rejected by server: %d %s", serr.Code, serr.Body)
+//	}
+//
+// [1] https://developers.mattermost.com/integrate/webhooks/incoming/
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEndpointEmpty define an error when sending post without endpoint.
+var ErrEndpointEmpty = errors.New("empty endpoint")
+
+// Client define the client to send post to Mattermost incoming webhook.
+//
+// The zero value of Client, with the Endpoint set, is ready to use.
+// The Client is safe to be used by multiple goroutines.
+type Client struct {
+	// HTTPClient define the HTTP client that will be used to send the
+	// request.
+	// Default to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Endpoint define the URL of incoming webhook, for example
+	// "https://my.mattermost.org/hooks/xxx".
+	Endpoint string
+
+	// Channel define the default channel name where the post is send,
+	// if the Payload Channel is empty.
+	Channel string
+
+	// Username define the default user name that send the post, if the
+	// Payload Username is empty.
+	Username string
+
+	// IconURL define the default URL of profile picture of the sender,
+	// if the Payload IconURL is empty.
+	IconURL string
+}
+
+// Post send the `payload` to Mattermost.
+// The empty Channel, Username, and IconURL in payload are set from the
+// Client.
+//
+// It will return ErrEndpointEmpty if the Client Endpoint is empty,
+// *StatusError if Mattermost response with non-2xx HTTP status code, or
+// the error from HTTPClient, for example network error or the context
+// `ctx` is done.
+func (cl *Client) Post(ctx context.Context, payload Payload) (err error) {
+	if len(payload.Channel) == 0 {
+		payload.Channel = cl.Channel
+	}
+	if len(payload.Username) == 0 {
+		payload.Username = cl.Username
+	}
+	if len(payload.IconURL) == 0 {
+		payload.IconURL = cl.IconURL
+	}
+
+	body, err := payload.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	_, err = cl.PostRaw(ctx, "", body)
+
+	return err
+}
+
+// PostRaw send the JSON encoded payload `body` to Mattermost `endpoint`
+// as is.
+// If the `endpoint` is empty, the Client Endpoint is used.
+//
+// On success it will return the HTTP response body.
+// The returned error is the same as Post.
+func (cl *Client) PostRaw(ctx context.Context, endpoint string, body []byte) (
+	resBody []byte, err error,
+) {
+	var (
+		httpCl = cl.HTTPClient
+		req    *http.Request
+		res    *http.Response
+	)
+
+	if len(endpoint) == 0 {
+		endpoint = cl.Endpoint
+	}
+	if len(endpoint) == 0 {
+		return nil, ErrEndpointEmpty
+	}
+	if httpCl == nil {
+		httpCl = http.DefaultClient
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err = httpCl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err = io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, newStatusError(res, resBody)
+	}
+
+	return resBody, nil
+}
+
+// StatusError define an error when Mattermost response with non-2xx HTTP
+// status code.
+type StatusError struct {
+	// Status define the HTTP status, for example "400 Bad Request".
+	Status string
+
+	// Body define the response body, usually contains the reason of
+	// error.
+	Body string
+
+	// Code define the HTTP status code.
+	Code int
+
+	// RetryAfter define the delay requested by server in the
+	// Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (serr *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", serr.Status, strings.TrimSpace(serr.Body))
+}
+
+// newStatusError create StatusError from HTTP response `res` and its
+// `body`.
+func newStatusError(res *http.Response, body []byte) (serr *StatusError) {
+	serr = &StatusError{
+		Code:   res.StatusCode,
+		Status: res.Status,
+		Body:   string(body),
+	}
+	serr.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"),
+		time.Now())
+	return serr
+}
+
+// parseRetryAfter parse the value of HTTP header Retry-After, in the form
+// of delay in seconds or HTTP date, into duration relative to `now`.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if len(v) == 0 {
+		return 0
+	}
+
+	sec, err := strconv.Atoi(v)
+	if err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+
+	date, err := http.ParseTime(v)
+	if err != nil {
+		return 0
+	}
+
+	delay := date.Sub(now)
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
@@ -0,0 +1,196 @@
+// Copyright 2023 M. Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func assert(t *testing.T, exp, got interface{}, equal bool) {
+	if reflect.DeepEqual(exp, got) != equal {
+		debug.PrintStack()
+		t.Fatalf("\n"+
+			">>> Expecting '%+v'\n"+
+			"          got '%+v'\n", exp, got)
+	}
+}
+
+func TestClientPost(t *testing.T) {
+	var (
+		gotBody        string
+		gotContentType string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			gotBody = string(body)
+			gotContentType = req.Header.Get("Content-Type")
+			_, _ = res.Write([]byte("ok"))
+		}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{
+		Endpoint: srv.URL,
+		Channel:  "town-square",
+		Username: "bot",
+		IconURL:  "https://example.com/bot.png",
+	}
+
+	tests := []struct {
+		desc    string
+		exp     string
+		payload Payload
+	}{
+		{
+			desc: "With default destination",
+			payload: Payload{
+				Text: "hello",
+			},
+			exp: `{"channel":"town-square","username":"bot","icon_url":"https://example.com/bot.png","text":"hello"}`,
+		},
+		{
+			desc: "With destination in payload",
+			payload: Payload{
+				Channel:  "ops",
+				Username: "deploy",
+				IconURL:  "https://example.com/deploy.png",
+				Attachments: []*Attachment{{
+					Color: "#FF0000",
+				}},
+			},
+			exp: `{"channel":"ops","username":"deploy","icon_url":"https://example.com/deploy.png","attachments":[{"color":"#FF0000"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		err := cl.Post(context.Background(), test.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, test.exp, gotBody, true)
+		assert(t, "application/json", gotContentType, true)
+	}
+}
+
+func TestClientPostError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Set("Retry-After", "3")
+			res.WriteHeader(http.StatusBadRequest)
+			_, _ = res.Write([]byte("Unable to parse incoming data\n"))
+		}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{}
+
+	err := cl.Post(context.Background(), Payload{Text: "hello"})
+	assert(t, ErrEndpointEmpty, err, true)
+
+	cl.Endpoint = srv.URL
+
+	err = cl.Post(context.Background(), Payload{Text: "hello"})
+
+	var serr *StatusError
+	if !errors.As(err, &serr) {
+		t.Fatalf("expecting *StatusError, got %T: %v", err, err)
+	}
+
+	exp := &StatusError{
+		Status:     "400 Bad Request",
+		Body:       "Unable to parse incoming data\n",
+		Code:       http.StatusBadRequest,
+		RetryAfter: 3 * time.Second,
+	}
+	assert(t, exp, serr, true)
+	assert(t, "400 Bad Request: Unable to parse incoming data", err.Error(), true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = cl.Post(ctx, Payload{Text: "hello"})
+	assert(t, true, errors.Is(err, context.Canceled), true)
+}
+
+func TestClientPostRaw(t *testing.T) {
+	var gotPath string
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(res http.ResponseWriter, req *http.Request) {
+			gotPath = req.URL.Path
+			_, _ = res.Write([]byte("ok"))
+		}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{
+		Endpoint:   srv.URL + "/hooks/default",
+		HTTPClient: srv.Client(),
+	}
+
+	resBody, err := cl.PostRaw(context.Background(), "", []byte(`{"text":"a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "ok", string(resBody), true)
+	assert(t, "/hooks/default", gotPath, true)
+
+	_, err = cl.PostRaw(context.Background(), srv.URL+"/hooks/other",
+		[]byte(`{"text":"a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "/hooks/other", gotPath, true)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 2, 18, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc string
+		in   string
+		exp  time.Duration
+	}{
+		{
+			desc: "With empty value",
+		},
+		{
+			desc: "With seconds",
+			in:   "120",
+			exp:  2 * time.Minute,
+		},
+		{
+			desc: "With HTTP date",
+			in:   "Sat, 18 Feb 2023 10:00:30 GMT",
+			exp:  30 * time.Second,
+		},
+		{
+			desc: "With past HTTP date",
+			in:   "Sat, 18 Feb 2023 09:00:00 GMT",
+		},
+		{
+			desc: "With invalid value",
+			in:   "soon",
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.desc)
+
+		got := parseRetryAfter(test.in, now)
+
+		assert(t, test.exp, got, true)
+	}
+}